@@ -0,0 +1,377 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrInvalidAdapter means the adapter sequence is empty or contains non-IUPAC letters.
+var ErrInvalidAdapter = errors.New("seq: invalid adapter")
+
+// Adapter is an adapter or primer sequence to trim. Degenerate bases
+// (IUPAC codes) are supported, e.g., N matches any base.
+//
+//   - Back only: a 3' adapter, which is removed along with the following bases.
+//     Partial adapters at the 3' end of reads are also detected.
+//   - Front only: a 5' adapter, which is removed along with the preceding bases.
+//     Partial adapters at the 5' end of reads are also detected.
+//   - Both: a linked adapter, where the 5' adapter is required, and the
+//     3' adapter is searched after it and optional.
+type Adapter struct {
+	Name  string
+	Front []byte // 5' adapter
+	Back  []byte // 3' adapter
+
+	// Anchored adapters must be fully present at the read ends, i.e., the 5' end
+	// for front adapters and the 3' end for back adapters. For linked adapters,
+	// only the front one is anchored.
+	Anchored bool
+}
+
+func (a *Adapter) String() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	if len(a.Front) > 0 && len(a.Back) > 0 {
+		return string(a.Front) + "..." + string(a.Back)
+	}
+	if len(a.Front) > 0 {
+		return string(a.Front) + "..."
+	}
+	return "..." + string(a.Back)
+}
+
+// AdapterTrimOptions contains the options of adapter trimming.
+type AdapterTrimOptions struct {
+	ErrorRate  float64 // maximum error rate, i.e., mismatches/overlap length
+	MinOverlap int     // minimum overlap of partial adapters at read ends
+
+	// PairedMinOverlap is the minimum overlap of paired-end reads
+	// for overlap detection in AdapterTrimmer.TrimPair.
+	PairedMinOverlap int
+}
+
+// DefaultAdapterTrimOptions is the default options of adapter trimming.
+var DefaultAdapterTrimOptions = AdapterTrimOptions{
+	ErrorRate:        0.1,
+	MinOverlap:       3,
+	PairedMinOverlap: 30,
+}
+
+// AdapterMatch is the result of trimming an adapter from a read.
+type AdapterMatch struct {
+	Adapter    *Adapter
+	Start, End int // 0-based half-open region of the read kept
+	Errors     int // number of mismatches
+}
+
+// AdapterHit is the number of reads an adapter was found in.
+type AdapterHit struct {
+	Adapter *Adapter
+	Hits    int64
+}
+
+// AdapterTrimmer trims adapters from reads, with per-adapter hits counted.
+// It's safe for concurrent use.
+type AdapterTrimmer struct {
+	adapters []*Adapter
+	opt      AdapterTrimOptions
+
+	hits       []int64
+	pairedHits int64
+}
+
+// iupac4 maps IUPAC nucleotide letters to 4-bit codes, 0 for others.
+var iupac4 [256]byte
+
+func init() {
+	var c int
+	var err error
+	for i := range iupac4 {
+		c, err = base2code(byte(i))
+		if err == nil {
+			iupac4[i] = byte(c)
+		}
+	}
+}
+
+// NewAdapterTrimmer creates an AdapterTrimmer. Nil opt is for DefaultAdapterTrimOptions.
+func NewAdapterTrimmer(adapters []*Adapter, opt *AdapterTrimOptions) (*AdapterTrimmer, error) {
+	if opt == nil {
+		opt = &DefaultAdapterTrimOptions
+	}
+	if opt.ErrorRate < 0 || opt.ErrorRate >= 1 {
+		return nil, fmt.Errorf("seq: invalid error rate of adapter trimming: %f", opt.ErrorRate)
+	}
+	for _, a := range adapters {
+		if len(a.Front) == 0 && len(a.Back) == 0 {
+			return nil, fmt.Errorf("%w: empty adapter: %s", ErrInvalidAdapter, a)
+		}
+		for _, s := range [][]byte{a.Front, a.Back} {
+			for _, b := range s {
+				if iupac4[b] == 0 {
+					return nil, fmt.Errorf("%w: %s: %c", ErrInvalidAdapter, a, b)
+				}
+			}
+		}
+	}
+	return &AdapterTrimmer{
+		adapters: adapters,
+		opt:      *opt,
+		hits:     make([]int64, len(adapters)),
+	}, nil
+}
+
+// mismatches counts mismatches between a read and an adapter of the same length,
+// and returns early if it exceeds maxErr. Degenerate bases in the read are
+// treated as mismatches.
+func mismatches(read, adapter []byte, maxErr int) int {
+	var n int
+	var r byte
+	for i, b := range read {
+		r = iupac4[b]
+		if (r != 1 && r != 2 && r != 4 && r != 8) || iupac4[adapter[i]]&r == 0 {
+			n++
+			if n > maxErr {
+				return n
+			}
+		}
+	}
+	return n
+}
+
+// searchBack searches a 3' adapter, and returns the start position of the
+// adapter in the read, and the score (matches - mismatches) and mismatches.
+// pos < 0 means not found.
+func (t *AdapterTrimmer) searchBack(read, adapter []byte, anchored bool) (pos, score, errs int) {
+	n, m := len(read), len(adapter)
+	minOverlap := t.opt.MinOverlap
+	if minOverlap > m || minOverlap < 1 {
+		minOverlap = m
+	}
+	pos, score = -1, -1
+	first := 0
+	if anchored {
+		first = n - m
+		if first < 0 {
+			return
+		}
+		minOverlap = m
+	}
+	var l, e, s, maxErr int
+	for p := first; p <= n-minOverlap; p++ {
+		l = n - p
+		if l > m {
+			l = m
+		}
+		maxErr = int(t.opt.ErrorRate * float64(l))
+		e = mismatches(read[p:p+l], adapter[:l], maxErr)
+		if e > maxErr {
+			continue
+		}
+		s = l - 2*e
+		if s > score {
+			pos, score, errs = p, s, e
+		}
+	}
+	return
+}
+
+// searchFront searches a 5' adapter, and returns the end position of the
+// adapter in the read, and the score (matches - mismatches) and mismatches.
+// pos < 0 means not found.
+func (t *AdapterTrimmer) searchFront(read, adapter []byte, anchored bool) (pos, score, errs int) {
+	n, m := len(read), len(adapter)
+	minOverlap := t.opt.MinOverlap
+	if minOverlap > m || minOverlap < 1 {
+		minOverlap = m
+	}
+	pos, score = -1, -1
+	last := n
+	if anchored {
+		if n < m {
+			return
+		}
+		minOverlap, last = m, m
+	}
+	var l, e, s, maxErr int
+	for q := minOverlap; q <= last; q++ {
+		l = q
+		if l > m {
+			l = m
+		}
+		maxErr = int(t.opt.ErrorRate * float64(l))
+		e = mismatches(read[q-l:q], adapter[m-l:], maxErr)
+		if e > maxErr {
+			continue
+		}
+		s = l - 2*e
+		if s > score {
+			pos, score, errs = q, s, e
+		}
+	}
+	return
+}
+
+// Trim searches all the adapters, trims the best matched one from the
+// read in place, and returns the match. Nil is returned if no adapters found.
+func (t *AdapterTrimmer) Trim(read *Seq) *AdapterMatch {
+	var best *AdapterMatch
+	bestScore := -1
+	n := len(read.Seq)
+	var start, end, score, errs, p, s, e, idx int
+	for i, a := range t.adapters {
+		start, end, score, errs = 0, n, 0, 0
+		if len(a.Front) > 0 {
+			p, s, e = t.searchFront(read.Seq, a.Front, a.Anchored)
+			if p < 0 {
+				continue
+			}
+			start, score, errs = p, s, e
+		}
+		if len(a.Back) > 0 {
+			p, s, e = t.searchBack(read.Seq[start:], a.Back, a.Anchored && len(a.Front) == 0)
+			if p >= 0 {
+				end, score, errs = start+p, score+s, errs+e
+			} else if len(a.Front) == 0 {
+				continue
+			}
+		}
+		if score > bestScore {
+			best = &AdapterMatch{Adapter: a, Start: start, End: end, Errors: errs}
+			bestScore, idx = score, i
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	read.keepRegion(best.Start, best.End)
+	atomic.AddInt64(&t.hits[idx], 1)
+	return best
+}
+
+// TrimPair trims adapters from paired-end reads in place. It detects the
+// overlap of the pair first, and if the insert is shorter than the reads,
+// the adapters after the insert are removed, i.e., reads are trimmed to the
+// insert size. If no overlaps are found, adapters are searched in both reads.
+func (t *AdapterTrimmer) TrimPair(read1, read2 *Seq) (m1, m2 *AdapterMatch, overlap *PairedOverlap) {
+	overlap = FindPairedOverlap(read1.Seq, read2.Seq, t.opt.PairedMinOverlap, t.opt.ErrorRate)
+	if overlap == nil {
+		return t.Trim(read1), t.Trim(read2), nil
+	}
+	var trimmed bool
+	if overlap.InsertSize < len(read1.Seq) {
+		read1.keepRegion(0, overlap.InsertSize)
+		trimmed = true
+	}
+	if overlap.InsertSize < len(read2.Seq) {
+		read2.keepRegion(0, overlap.InsertSize)
+		trimmed = true
+	}
+	if trimmed {
+		atomic.AddInt64(&t.pairedHits, 1)
+	}
+	return nil, nil, overlap
+}
+
+// Hits returns the number of reads each adapter was found in.
+func (t *AdapterTrimmer) Hits() []AdapterHit {
+	hits := make([]AdapterHit, len(t.adapters))
+	for i, a := range t.adapters {
+		hits[i] = AdapterHit{Adapter: a, Hits: atomic.LoadInt64(&t.hits[i])}
+	}
+	return hits
+}
+
+// PairedHits returns the number of read pairs trimmed by overlap detection.
+func (t *AdapterTrimmer) PairedHits() int64 {
+	return atomic.LoadInt64(&t.pairedHits)
+}
+
+// ------------------------------------------------------------------------
+
+// PairedOverlap is the overlap of a read pair, where the reverse complement
+// of read 2 is aligned to read 1.
+type PairedOverlap struct {
+	// Offset is the 0-based position of the first base of the reverse complement
+	// of read 2 in read 1. Negative values mean the insert is shorter than read 2.
+	Offset     int
+	Length     int // overlap length
+	Mismatches int
+	InsertSize int // i.e., Offset + length of read 2
+}
+
+// FindPairedOverlap finds the longest overlap of a read pair, with the length
+// >= minOverlap and the mismatch rate <= maxMismatchRate. Nil is returned
+// if no overlaps are found.
+func FindPairedOverlap(read1, read2 []byte, minOverlap int, maxMismatchRate float64) *PairedOverlap {
+	n1, n2 := len(read1), len(read2)
+	if minOverlap < 1 {
+		minOverlap = 1
+	}
+	if n1 < minOverlap || n2 < minOverlap {
+		return nil
+	}
+
+	rc := make([]byte, n2)
+	for i, b := range read2 {
+		rc[n2-1-i] = complementNucleotide(b)
+	}
+
+	check := func(o, l int) *PairedOverlap {
+		b1, b2 := o, 0
+		if o < 0 {
+			b1, b2 = 0, -o
+		}
+		maxErr := int(maxMismatchRate * float64(l))
+		if e := countMismatches(read1[b1:b1+l], rc[b2:b2+l], maxErr); e <= maxErr {
+			return &PairedOverlap{Offset: o, Length: l, Mismatches: e, InsertSize: o + n2}
+		}
+		return nil
+	}
+
+	// Longer overlaps first, and inserts longer than read 2 (offset >= 0)
+	// are preferred. The longest overlaps are on offsets in [min(0, n1-n2),
+	// max(0, n1-n2)], and the overlap length decreases by 1 for each offset
+	// moved away from them on both sides.
+	lMax, lo, hi := n1, n1-n2, 0
+	if n1 >= n2 {
+		lMax, lo, hi = n2, 0, n1-n2
+	}
+	var p *PairedOverlap
+	for o := 0; o <= hi; o++ {
+		if p = check(o, lMax); p != nil {
+			return p
+		}
+	}
+	for o := lo; o < 0; o++ {
+		if p = check(o, lMax); p != nil {
+			return p
+		}
+	}
+	for l := lMax - 1; l >= minOverlap; l-- {
+		if p = check(n1-l, l); p != nil {
+			return p
+		}
+		if p = check(l-n2, l); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// countMismatches counts case-insensitive mismatches of two sequences of
+// the same length, and returns early if it exceeds maxErr.
+func countMismatches(s1, s2 []byte, maxErr int) int {
+	var n int
+	for i, b := range s1 {
+		if toUpper(b) != toUpper(s2[i]) {
+			n++
+			if n > maxErr {
+				return n
+			}
+		}
+	}
+	return n
+}
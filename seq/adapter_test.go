@@ -0,0 +1,97 @@
+package seq
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAdapterTrimmer(t *testing.T) {
+	illumina := &Adapter{Name: "Illumina", Back: []byte("AGATCGGAAGAGC")}
+	primer := &Adapter{Name: "515F", Front: []byte("GTGYCAGCMGCCGCGGTAA"), Anchored: true}
+	linked := &Adapter{Name: "linked", Front: []byte("CTTGGTCATTTAGAGGAAGTAA"), Back: []byte("GCATCGATGAAGAACGCAGC")}
+
+	trimmer, err := NewAdapterTrimmer([]*Adapter{illumina, primer, linked}, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	insert := "ACGTTGCAAGGCTTAACCTG"
+	cases := []struct {
+		read     string
+		adapter  *Adapter
+		expected string
+	}{
+		{insert + "AGATCGGAAG", illumina, insert},                                         // full
+		{insert + "AGAT", illumina, insert},                                               // partial
+		{insert + "AGATCGTAAGAGCAAAA", illumina, insert},                                  // one mismatch
+		{insert, nil, insert},                                                             // none
+		{"GTGCCAGCAGCCGCGGTAA" + insert, primer, insert},                                  // degenerate
+		{"A" + "GTGCCAGCAGCCGCGGTAA" + insert, nil, "A" + "GTGCCAGCAGCCGCGGTAA" + insert}, // not anchored
+		{"GGCTTGGTCATTTAGAGGAAGTAA" + insert + "GCATCGATGAAGAACGCAGCAA", linked, insert},
+		{"CTTGGTCATTTAGAGGAAGTAA" + insert, linked, insert}, // the 3' adapter is optional
+	}
+
+	var s *Seq
+	var m *AdapterMatch
+	for i, c := range cases {
+		s, _ = NewSeqWithQual(DNAredundant, []byte(c.read), make([]byte, len(c.read)))
+		m = trimmer.Trim(s)
+		if string(s.Seq) != c.expected || len(s.Qual) != len(s.Seq) {
+			t.Errorf("case %d: adapter trimming error: %s, expected: %s", i, s.Seq, c.expected)
+		}
+		if c.adapter == nil {
+			if m != nil {
+				t.Errorf("case %d: unexpected adapter: %s", i, m.Adapter)
+			}
+			continue
+		}
+		if m == nil || m.Adapter != c.adapter {
+			t.Errorf("case %d: adapter error: %v", i, m)
+		}
+	}
+
+	hits := trimmer.Hits()
+	if hits[0].Hits != 3 || hits[1].Hits != 1 || hits[2].Hits != 2 {
+		t.Errorf("adapter hits error: %v", hits)
+	}
+
+	if _, err = NewAdapterTrimmer([]*Adapter{{Back: []byte("ACGTZ")}}, nil); !errors.Is(err, ErrInvalidAdapter) {
+		t.Errorf("invalid adapter should be reported")
+	}
+}
+
+func TestTrimPair(t *testing.T) {
+	trimmer, err := NewAdapterTrimmer(nil, &AdapterTrimOptions{ErrorRate: 0.1, MinOverlap: 3, PairedMinOverlap: 15})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	insert, _ := NewSeq(DNA, []byte("ACGTTGCAAGGCTTAACCTGGATC"))
+	r1, _ := NewSeq(DNA, []byte(string(insert.Seq)+"AGATCGGAAGAGCACA"))
+	r2, _ := NewSeq(DNA, []byte(string(insert.RevCom().Seq)+"AGATCGGAAGAGCGTC"))
+	_, _, overlap := trimmer.TrimPair(r1, r2)
+	if overlap == nil || overlap.InsertSize != len(insert.Seq) {
+		t.Errorf("overlap detection error: %v", overlap)
+		return
+	}
+	if string(r1.Seq) != string(insert.Seq) || string(r2.Seq) != string(insert.RevCom().Seq) {
+		t.Errorf("paired-end trimming error: %s, %s", r1.Seq, r2.Seq)
+	}
+	if trimmer.PairedHits() != 1 {
+		t.Errorf("paired hits error: %d", trimmer.PairedHits())
+	}
+
+	// long insert
+	frag, _ := NewSeq(DNA, []byte("ACGTTGCAAGGCTTAACCTGGATCCATGCAGTTGACCAGT"))
+	r1, _ = NewSeq(DNA, []byte(string(frag.Seq[:30])))
+	r2 = frag.SubSeq(11, 40).RevCom()
+	_, _, overlap = trimmer.TrimPair(r1, r2)
+	if overlap == nil || overlap.InsertSize != 40 || overlap.Offset != 10 || overlap.Length != 20 {
+		t.Errorf("overlap detection error: %v", overlap)
+	}
+	if len(r1.Seq) != 30 || len(r2.Seq) != 30 {
+		t.Errorf("reads of long inserts should not be trimmed")
+	}
+}
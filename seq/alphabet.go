@@ -96,6 +96,9 @@ For example, DNA:
 		[]byte("tgcaTGCA"),
 		[]byte(" -"),
 		[]byte("nN"))
+
+Custom alphabets with case policies and symbol classes could be
+created with AlphabetBuilder.
 */
 type Alphabet struct {
 	t         string
@@ -109,6 +112,9 @@ type Alphabet struct {
 	allLetters []byte
 
 	pairLetters []byte
+
+	casePolicy CasePolicy
+	classes    []SymbolClass // index are the integer of a byte
 }
 
 // NewAlphabet is Constructor for type *Alphabet*
@@ -121,7 +127,16 @@ func NewAlphabet(
 	ambiguous []byte,
 ) (*Alphabet, error) {
 
-	a := &Alphabet{t, isUnlimit, letters, pairs, gap, ambiguous, []byte{}, []byte{}}
+	a := &Alphabet{
+		t:           t,
+		isUnlimit:   isUnlimit,
+		letters:     letters,
+		pairs:       pairs,
+		gap:         gap,
+		ambiguous:   ambiguous,
+		allLetters:  []byte{},
+		pairLetters: []byte{},
+	}
 
 	if isUnlimit {
 		return a, nil
@@ -159,6 +174,17 @@ func NewAlphabet(
 		a.pairLetters[v-'\x00'] = v
 	}
 
+	a.classes = make([]SymbolClass, max+1)
+	for _, v := range letters {
+		a.classes[v] |= SymbolLetter
+	}
+	for _, v := range gap {
+		a.classes[v] |= SymbolGap
+	}
+	for _, v := range ambiguous {
+		a.classes[v] |= SymbolAmbiguous
+	}
+
 	return a, nil
 }
 
@@ -173,6 +199,8 @@ func (a *Alphabet) Clone() *Alphabet {
 		ambiguous:   []byte(string(a.ambiguous)),
 		allLetters:  []byte(string(a.allLetters)),
 		pairLetters: []byte(string(a.pairLetters)),
+		casePolicy:  a.casePolicy,
+		classes:     append([]SymbolClass(nil), a.classes...),
 	}
 
 }
@@ -398,6 +426,8 @@ func init() {
 		nil,
 		nil)
 
+	Protein.classes['*'] |= SymbolStop
+
 	abProtein = slice2map(byteutil.Alphabet(Protein.AllLetters()))
 	abDNAredundant = slice2map(byteutil.Alphabet(DNAredundant.AllLetters()))
 	abDNA = slice2map(byteutil.Alphabet(DNA.AllLetters()))
@@ -409,7 +439,8 @@ func init() {
 // based which FastaRecord guesses the sequence type. 0 for whole seq
 var AlphabetGuessSeqLengthThreshold = 10000
 
-// GuessAlphabet guesses alphabet by given
+// GuessAlphabet guesses alphabet by given sequence.
+// Custom alphabets could also be chosen, see RegisterAlphabet.
 func GuessAlphabet(seqs []byte) *Alphabet {
 	if len(seqs) == 0 {
 		return Unlimit
@@ -420,6 +451,11 @@ func GuessAlphabet(seqs []byte) *Alphabet {
 	} else { // reduce guessing time
 		alphabetMap = slice2map(byteutil.Alphabet(seqs[0:AlphabetGuessSeqLengthThreshold]))
 	}
+	for _, r := range preferredAlphabets {
+		if isSubset(alphabetMap, r.set) {
+			return r.alphabet
+		}
+	}
 	if isSubset(alphabetMap, abDNA) {
 		return DNA
 	}
@@ -435,6 +471,11 @@ func GuessAlphabet(seqs []byte) *Alphabet {
 	if isSubset(alphabetMap, abProtein) {
 		return Protein
 	}
+	for _, r := range customAlphabets {
+		if isSubset(alphabetMap, r.set) {
+			return r.alphabet
+		}
+	}
 
 	return Unlimit
 }
@@ -0,0 +1,288 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/elliotwutingfeng/asciiset"
+	"github.com/shenwei356/util/byteutil"
+)
+
+// CasePolicy defines how the cases of letters are handled by an Alphabet.
+type CasePolicy int
+
+const (
+	// CaseSensitive means letters are valid only in the given cases.
+	CaseSensitive CasePolicy = iota
+	// CaseInsensitive means letters are valid in both cases,
+	// and lower-case letters are the same as upper-case ones.
+	CaseInsensitive
+	// CaseSoftMasked means letters are valid in both cases, and lower-case
+	// forms of upper-case symbols are soft-masked, i.e., in the class of
+	// SymbolMask. Lower-case symbols given explicitly keep their own classes.
+	CaseSoftMasked
+)
+
+func (p CasePolicy) String() string {
+	switch p {
+	case CaseSensitive:
+		return "case-sensitive"
+	case CaseInsensitive:
+		return "case-insensitive"
+	case CaseSoftMasked:
+		return "soft-masked"
+	}
+	return fmt.Sprintf("CasePolicy(%d)", int(p))
+}
+
+// SymbolClass is the class of a symbol in an Alphabet.
+// A symbol could belong to multiple classes, e.g., SymbolLetter|SymbolMask.
+type SymbolClass uint8
+
+const (
+	SymbolLetter       SymbolClass = 1 << iota // ordinary letters, e.g., A, C, G, T
+	SymbolAmbiguous                            // ambiguous letters, e.g., N, X
+	SymbolGap                                  // gaps, e.g., -, .
+	SymbolStop                                 // stops, e.g., *
+	SymbolMask                                 // masked letters, e.g., soft-masked bases
+	SymbolModification                         // modified residues, e.g., m for 5mC
+)
+
+// Class returns the symbol class of a letter, 0 for invalid letters.
+func (a *Alphabet) Class(b byte) SymbolClass {
+	if int(b) >= len(a.classes) {
+		return 0
+	}
+	return a.classes[b]
+}
+
+// CasePolicy returns the case policy of the alphabet.
+func (a *Alphabet) CasePolicy() CasePolicy {
+	return a.casePolicy
+}
+
+// IsGap tells if the letter is a gap.
+func (a *Alphabet) IsGap(b byte) bool { return a.Class(b)&SymbolGap > 0 }
+
+// IsStop tells if the letter is a stop.
+func (a *Alphabet) IsStop(b byte) bool { return a.Class(b)&SymbolStop > 0 }
+
+// IsMasked tells if the letter is masked.
+func (a *Alphabet) IsMasked(b byte) bool { return a.Class(b)&SymbolMask > 0 }
+
+// ErrInvalidAlphabet means the definition of the alphabet is invalid.
+var ErrInvalidAlphabet = errors.New("seq: invalid alphabet")
+
+// AlphabetBuilder is used to define custom alphabets. For example, DNA with
+// soft-masked bases, and '*' and '~' as stop and gap:
+//
+//	alphabet, err := NewAlphabetBuilder("DNAsoftmasked").
+//		SetCasePolicy(CaseSoftMasked).
+//		AddSymbols(SymbolLetter, []byte("ACGT"), []byte("TGCA")).
+//		AddSymbols(SymbolAmbiguous, []byte("N"), nil).
+//		AddSymbols(SymbolGap, []byte("-~"), nil).
+//		AddSymbols(SymbolStop, []byte("*"), nil).
+//		Build()
+type AlphabetBuilder struct {
+	name       string
+	casePolicy CasePolicy
+
+	symbols     []byte
+	complements []byte
+	classes     []SymbolClass
+
+	err error
+}
+
+// NewAlphabetBuilder creates an AlphabetBuilder for an alphabet of the given name.
+func NewAlphabetBuilder(name string) *AlphabetBuilder {
+	return &AlphabetBuilder{name: name}
+}
+
+// SetCasePolicy sets the case policy, the default one is CaseSensitive.
+func (b *AlphabetBuilder) SetCasePolicy(p CasePolicy) *AlphabetBuilder {
+	b.casePolicy = p
+	return b
+}
+
+// AddSymbols adds symbols of a class, with their complements.
+// Nil complements means the symbols are complements of themselves.
+func (b *AlphabetBuilder) AddSymbols(class SymbolClass, symbols []byte, complements []byte) *AlphabetBuilder {
+	if b.err != nil {
+		return b
+	}
+	if complements == nil {
+		complements = symbols
+	} else if len(complements) != len(symbols) {
+		b.err = fmt.Errorf("%w: mismatch of length of symbols and complements: %s, %s",
+			ErrInvalidAlphabet, symbols, complements)
+		return b
+	}
+	for i, s := range symbols {
+		b.symbols = append(b.symbols, s)
+		b.complements = append(b.complements, complements[i])
+		b.classes = append(b.classes, class)
+	}
+	return b
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// switchCase switches the case of ASCII letters, and leaves others unchanged.
+func switchCase(b byte) byte {
+	if isASCIILetter(b) {
+		return b ^ 0x20
+	}
+	return b
+}
+
+// Build creates the alphabet. Symbols defined more than once must have
+// the same complement, and their classes are merged.
+func (b *AlphabetBuilder) Build() (*Alphabet, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.symbols) == 0 {
+		return nil, fmt.Errorf("%w: no symbols given", ErrInvalidAlphabet)
+	}
+
+	var pairs [256]byte
+	var classes [256]SymbolClass
+	order := make([]byte, 0, len(b.symbols)*2)
+	add := func(s, c byte, class SymbolClass) error {
+		if pairs[s] != 0 {
+			if pairs[s] != c {
+				return fmt.Errorf("%w: conflicting complements of %c: %c, %c", ErrInvalidAlphabet, s, pairs[s], c)
+			}
+		} else {
+			order = append(order, s)
+			pairs[s] = c
+		}
+		classes[s] |= class
+		return nil
+	}
+
+	var s, c byte
+	var class SymbolClass
+	var err error
+	for i := range b.symbols {
+		s, c, class = b.symbols[i], b.complements[i], b.classes[i]
+		if err = add(s, c, class); err != nil {
+			return nil, err
+		}
+	}
+	if b.casePolicy != CaseSensitive {
+		for i := range b.symbols {
+			s, c, class = b.symbols[i], b.complements[i], b.classes[i]
+			if !isASCIILetter(s) {
+				continue
+			}
+			s, c = switchCase(s), switchCase(c)
+			if b.casePolicy == CaseSoftMasked && s >= 'a' && s <= 'z' {
+				class |= SymbolMask
+			}
+			if err = add(s, c, class); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	letters := make([]byte, 0, len(order))
+	complements := make([]byte, 0, len(order))
+	gap := make([]byte, 0, 4)
+	others := make([]byte, 0, 8)
+	for _, s = range order {
+		switch {
+		case classes[s]&SymbolGap > 0:
+			gap = append(gap, s)
+		case pairs[s] != s || classes[s]&SymbolLetter > 0:
+			letters = append(letters, s)
+			complements = append(complements, pairs[s])
+		default:
+			others = append(others, s)
+		}
+	}
+
+	a, err := NewAlphabet(b.name, false, letters, complements, gap, others)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAlphabet, err)
+	}
+	a.casePolicy = b.casePolicy
+	for _, s = range order {
+		a.classes[s] = classes[s]
+	}
+	return a, nil
+}
+
+// ------------------------------------------------------------------------
+
+type registeredAlphabet struct {
+	alphabet *Alphabet
+	set      asciiset.ASCIISet
+}
+
+// custom alphabets checked before and after the built-in ones in GuessAlphabet.
+var preferredAlphabets, customAlphabets []registeredAlphabet
+
+// ErrDuplicatedAlphabet means an alphabet of the same name has been registered.
+var ErrDuplicatedAlphabet = errors.New("seq: duplicated alphabet")
+
+// RegisterAlphabet registers a custom alphabet, so that GuessAlphabet could choose it.
+// Preferred alphabets are checked before the built-in ones, others are checked
+// after them (but before Unlimit), in the order of registration.
+// The first alphabet containing all the letters of a sequence is chosen.
+//
+// The registry is not protected by any lock, so please register custom
+// alphabets before guessing alphabets concurrently.
+func RegisterAlphabet(a *Alphabet, preferred bool) error {
+	if a == nil || a.isUnlimit {
+		return fmt.Errorf("%w: nil or unlimited alphabet", ErrInvalidAlphabet)
+	}
+	for _, b := range []*Alphabet{DNA, DNAredundant, RNA, RNAredundant, Protein, Unlimit} {
+		if a.t == b.t {
+			return fmt.Errorf("%w: %s", ErrDuplicatedAlphabet, a.t)
+		}
+	}
+	for _, r := range RegisteredAlphabets() {
+		if a.t == r.t {
+			return fmt.Errorf("%w: %s", ErrDuplicatedAlphabet, a.t)
+		}
+	}
+
+	r := registeredAlphabet{alphabet: a, set: slice2map(byteutil.Alphabet(a.AllLetters()))}
+	if preferred {
+		preferredAlphabets = append(preferredAlphabets, r)
+	} else {
+		customAlphabets = append(customAlphabets, r)
+	}
+	return nil
+}
+
+// UnregisterAlphabet removes a registered custom alphabet by name,
+// and reports whether it was found.
+func UnregisterAlphabet(name string) bool {
+	for _, list := range []*[]registeredAlphabet{&preferredAlphabets, &customAlphabets} {
+		for i, r := range *list {
+			if r.alphabet.t == name {
+				*list = append((*list)[:i], (*list)[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RegisteredAlphabets returns all the registered custom alphabets,
+// preferred ones first.
+func RegisteredAlphabets() []*Alphabet {
+	alphabets := make([]*Alphabet, 0, len(preferredAlphabets)+len(customAlphabets))
+	for _, r := range preferredAlphabets {
+		alphabets = append(alphabets, r.alphabet)
+	}
+	for _, r := range customAlphabets {
+		alphabets = append(alphabets, r.alphabet)
+	}
+	return alphabets
+}
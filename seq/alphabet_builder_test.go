@@ -0,0 +1,100 @@
+package seq
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAlphabetBuilder(t *testing.T) {
+	a, err := NewAlphabetBuilder("DNAsoftmasked").
+		SetCasePolicy(CaseSoftMasked).
+		AddSymbols(SymbolLetter, []byte("ACGT"), []byte("TGCA")).
+		AddSymbols(SymbolAmbiguous, []byte("N"), nil).
+		AddSymbols(SymbolGap, []byte("-~"), nil).
+		AddSymbols(SymbolStop, []byte("*"), nil).
+		AddSymbols(SymbolModification, []byte("m"), nil). // 5mC
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err = a.IsValid([]byte("ACGTacgtNn-~*")); err != nil {
+		t.Error(err)
+	}
+	if a.IsValid([]byte("ACGU")) == nil {
+		t.Errorf("invalid letter should be reported")
+	}
+	if !a.IsMasked('a') || a.IsMasked('A') || !a.IsGap('~') || !a.IsStop('*') {
+		t.Errorf("symbol classes error")
+	}
+	if a.Class('g') != SymbolLetter|SymbolMask || a.Class('n') != SymbolAmbiguous|SymbolMask {
+		t.Errorf("symbol classes error: %d, %d", a.Class('g'), a.Class('n'))
+	}
+	if a.Class('m') != SymbolModification || a.IsMasked('M') {
+		t.Errorf("lower-case symbols given explicitly should not be masked")
+	}
+
+	s, err := NewSeq(a, []byte("ACgtn~*"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(s.RevCom().Seq) != "*~nacGT" {
+		t.Errorf("revcom with custom alphabet error: %s", s.RevCom().Seq)
+	}
+
+	// conflicting complements
+	_, err = NewAlphabetBuilder("bad").
+		AddSymbols(SymbolLetter, []byte("AT"), []byte("TA")).
+		AddSymbols(SymbolLetter, []byte("A"), []byte("A")).
+		Build()
+	if !errors.Is(err, ErrInvalidAlphabet) {
+		t.Errorf("conflicting complements should be reported")
+	}
+
+	// case sensitive
+	a2, _ := NewAlphabetBuilder("upper").
+		AddSymbols(SymbolLetter, []byte("ACGT"), []byte("TGCA")).
+		Build()
+	if a2.IsValid([]byte("acgt")) == nil {
+		t.Errorf("lower-case letters should be invalid")
+	}
+}
+
+func TestRegisterAlphabet(t *testing.T) {
+	a, _ := NewAlphabetBuilder("DNAwithStop").
+		SetCasePolicy(CaseInsensitive).
+		AddSymbols(SymbolLetter, []byte("ACGT"), []byte("TGCA")).
+		AddSymbols(SymbolStop, []byte("*"), nil).
+		AddSymbols(SymbolGap, []byte("~"), nil).
+		Build()
+
+	if GuessAlphabet([]byte("ACGT~*")) != Unlimit {
+		t.Errorf("unregistered alphabet should not be chosen")
+	}
+
+	if err := RegisterAlphabet(a, false); err != nil {
+		t.Error(err)
+		return
+	}
+	defer UnregisterAlphabet(a.String())
+
+	if err := RegisterAlphabet(a, false); !errors.Is(err, ErrDuplicatedAlphabet) {
+		t.Errorf("duplicated alphabet should be reported")
+	}
+
+	if GuessAlphabet([]byte("ACGT~*")) != a {
+		t.Errorf("registered alphabet should be chosen")
+	}
+	if GuessAlphabet([]byte("ACGT")) != DNA {
+		t.Errorf("built-in alphabet should be chosen first")
+	}
+
+	// preferred
+	UnregisterAlphabet(a.String())
+	RegisterAlphabet(a, true)
+	if GuessAlphabet([]byte("ACGT")) != a {
+		t.Errorf("preferred alphabet should be chosen first")
+	}
+}
@@ -0,0 +1,200 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManySeqs means the number of sequences exceeds the limit.
+var ErrTooManySeqs = errors.New("seq: too many sequences")
+
+// ErrUnknownAminoAcid means the amino acid is not coded by any codon of the codon table.
+var ErrUnknownAminoAcid = errors.New("seq: unknown amino acid")
+
+// ambiguousAminoAcids maps ambiguous amino acid codes to the amino acids they represent.
+var ambiguousAminoAcids = map[byte][]byte{
+	'B': []byte("DN"),
+	'Z': []byte("EQ"),
+	'J': []byte("IL"),
+}
+
+// SynonymousCodons returns all the unambiguous codons (in the order of NCBI
+// codon tables) coding the amino acid (case insensitive). '*' is for stop codons.
+// Ambiguous amino acids B, Z and J are also supported.
+func (t *CodonTable) SynonymousCodons(aa byte) [][]byte {
+	if aa >= 'a' && aa <= 'z' {
+		aa -= 32
+	}
+	aas, ok := ambiguousAminoAcids[aa]
+	if !ok {
+		aas = []byte{aa}
+	}
+
+	codons := make([][]byte, 0, 6)
+	var codon []byte
+	var a byte
+	for i := 0; i < 64; i++ {
+		codon = index2codon(i)
+		a, _ = t.Get(codon, true)
+		for _, b := range aas {
+			if a == b {
+				codons = append(codons, codon)
+				break
+			}
+		}
+	}
+	return codons
+}
+
+// degenerateCodon returns the most degenerate codon representing all the codons.
+func degenerateCodon(codons [][]byte) []byte {
+	var codes [3]int
+	var c int
+	for _, codon := range codons {
+		for i := 0; i < 3; i++ {
+			c, _ = base2code(codon[i])
+			codes[i] |= c
+		}
+	}
+	return []byte{code2base[codes[0]], code2base[codes[1]], code2base[codes[2]]}
+}
+
+// backTranslationTable returns the codons of all amino acids,
+// degenerate for the most degenerate codon, and all for all the codons.
+func (t *CodonTable) backTranslationTable(protein []byte) (degenerate [256][]byte, all [256][][]byte, err error) {
+	var codons [][]byte
+	for _, aa := range protein {
+		if degenerate[aa] != nil {
+			continue
+		}
+		switch aa {
+		case 'X', 'x':
+			degenerate[aa] = []byte("NNN")
+			all[aa] = [][]byte{[]byte("NNN")}
+			continue
+		case '-', '.':
+			degenerate[aa] = []byte{aa, aa, aa}
+			all[aa] = [][]byte{degenerate[aa]}
+			continue
+		}
+		codons = t.SynonymousCodons(aa)
+		if len(codons) == 0 {
+			return degenerate, all, fmt.Errorf("%w: %c", ErrUnknownAminoAcid, aa)
+		}
+		degenerate[aa] = degenerateCodon(codons)
+		all[aa] = codons
+	}
+	return degenerate, all, nil
+}
+
+// BackTranslate back-translates a protein sequence to a DNA sequence, where each
+// amino acid is represented by the most degenerate codon (IUPAC code) of its
+// synonymous codons, e.g., GCN for A and YTN for L in the standard code.
+// X is back-translated to NNN, and gaps ('-' and '.') to three gaps.
+func (t *CodonTable) BackTranslate(protein []byte) ([]byte, error) {
+	degenerate, _, err := t.backTranslationTable(protein)
+	if err != nil {
+		return nil, err
+	}
+	dna := make([]byte, 0, len(protein)*3)
+	for _, aa := range protein {
+		dna = append(dna, degenerate[aa]...)
+	}
+	return dna, nil
+}
+
+// BackTranslateAll returns all possible DNA sequences of a protein sequence.
+// X is back-translated to NNN, and gaps ('-' and '.') to three gaps.
+// ErrTooManySeqs is returned if the number of sequences is larger than max.
+func (t *CodonTable) BackTranslateAll(protein []byte, max int) ([][]byte, error) {
+	_, all, err := t.backTranslationTable(protein)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 1
+	for _, aa := range protein {
+		n *= len(all[aa])
+		if n > max {
+			return nil, fmt.Errorf("%w: > %d", ErrTooManySeqs, max)
+		}
+	}
+
+	dseqs := make([][]byte, 1, n)
+	dseqs[0] = make([]byte, 0, len(protein)*3)
+	var codons [][]byte
+	var i, j int
+	for _, aa := range protein {
+		codons = all[aa]
+		// 2nd and more
+		more := make([][]byte, 0, len(dseqs)*(len(codons)-1))
+		for i = 1; i < len(codons); i++ {
+			for j = 0; j < len(dseqs); j++ {
+				s := make([]byte, len(dseqs[j]), len(protein)*3)
+				copy(s, dseqs[j])
+				more = append(more, append(s, codons[i]...))
+			}
+		}
+		// 1th
+		for i = 0; i < len(dseqs); i++ {
+			dseqs[i] = append(dseqs[i], codons[0]...)
+		}
+		dseqs = append(dseqs, more...)
+	}
+	return dseqs, nil
+}
+
+// BackTranslate back-translates a protein sequence to a DNA sequence, using
+// the most frequent codon of each amino acid in the codon usage table.
+// For ties, the first codon in the order of NCBI codon tables is chosen.
+// Amino acids absent in the codon usage table are represented by the most
+// degenerate codon as CodonTable.BackTranslate does.
+func (u *CodonUsage) BackTranslate(protein []byte) ([]byte, error) {
+	degenerate, _, err := u.Table.backTranslationTable(protein)
+	if err != nil {
+		return nil, err
+	}
+
+	var best [256][]byte
+	var maxes [256]float64
+	var aa byte
+	for i := 0; i < 64; i++ {
+		aa = u.aas[i]
+		if u.counts[i] > maxes[aa] {
+			maxes[aa] = u.counts[i]
+			best[aa] = index2codon(i)
+		}
+	}
+
+	dna := make([]byte, 0, len(protein)*3)
+	for _, aa = range protein {
+		if aa >= 'a' && aa <= 'z' {
+			if codon := best[aa-32]; codon != nil {
+				dna = append(dna, codon...)
+				continue
+			}
+		} else if codon := best[aa]; codon != nil {
+			dna = append(dna, codon...)
+			continue
+		}
+		dna = append(dna, degenerate[aa]...)
+	}
+	return dna, nil
+}
+
+// BackTranslate back-translates a protein sequence to a degenerate DNA sequence
+// (see CodonTable.BackTranslate) with the given codon table.
+func (seq *Seq) BackTranslate(transl_table int) (*Seq, error) {
+	if seq.Alphabet != Protein {
+		return nil, fmt.Errorf("seq: only protein sequence can call method BackTranslate, the alphabet is %s", seq.Alphabet.String())
+	}
+	codonTable, ok := CodonTables[transl_table]
+	if !ok {
+		return nil, fmt.Errorf("seq: invalid codon table: %d", transl_table)
+	}
+	dna, err := codonTable.BackTranslate(seq.Seq)
+	if err != nil {
+		return nil, err
+	}
+	return NewSeqWithoutValidation(DNAredundant, dna)
+}
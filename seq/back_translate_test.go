@@ -0,0 +1,81 @@
+package seq
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBackTranslate(t *testing.T) {
+	table := CodonTables[1]
+
+	dna, err := table.BackTranslate([]byte("MALX*"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(dna) != "ATGGCNYTNNNNTRR" {
+		t.Errorf("back-translation error: %s", dna)
+	}
+
+	dna, _ = table.BackTranslate([]byte("BZ"))
+	if string(dna) != "RAYSAR" {
+		t.Errorf("back-translation error: %s", dna)
+	}
+
+	_, err = table.BackTranslate([]byte("MO"))
+	if !errors.Is(err, ErrUnknownAminoAcid) {
+		t.Errorf("unknown amino acid should be reported")
+	}
+
+	p, _ := NewSeq(Protein, []byte("MKW"))
+	s, err := p.BackTranslate(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if s.Alphabet != DNAredundant || string(s.Seq) != "ATGAARTGG" {
+		t.Errorf("back-translation error: %s", s.Seq)
+	}
+}
+
+func TestBackTranslateAll(t *testing.T) {
+	table := CodonTables[1]
+	dseqs, err := table.BackTranslateAll([]byte("MAK"), 100)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(dseqs) != 8 {
+		t.Errorf("back-translation number error: %d", len(dseqs))
+	}
+	seen := make(map[string]struct{}, len(dseqs))
+	var aa []byte
+	for _, s := range dseqs {
+		seen[string(s)] = struct{}{}
+		aa, _ = table.Translate(s, 1, false, false, false, false)
+		if string(aa) != "MAK" {
+			t.Errorf("back-translation error: %s", s)
+		}
+	}
+	if len(seen) != 8 {
+		t.Errorf("duplicated back-translations")
+	}
+
+	_, err = table.BackTranslateAll([]byte("LLLL"), 100)
+	if !errors.Is(err, ErrTooManySeqs) {
+		t.Errorf("ErrTooManySeqs should be reported")
+	}
+}
+
+func TestBackTranslateWithCodonUsage(t *testing.T) {
+	u := NewCodonUsage(CodonTables[1])
+	u.Add([]byte("ATGGCAGCAGCTCTG"), 1)
+	dna, err := u.BackTranslate([]byte("MAaLK"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(dna) != "ATGGCAGCACTGAAR" {
+		t.Errorf("back-translation error: %s", dna)
+	}
+}
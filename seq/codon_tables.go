@@ -196,6 +196,91 @@ func (t *CodonTable) Clone() CodonTable {
 	return CodonTable{ID: t.ID, Name: t.Name, InitCodons: initCodons, StopCodons: stopCodons, table: table}
 }
 
+// GetDegenerate returns the amino acid of the codon ([]byte), where codons
+// containing IUPAC ambiguous bases are expanded to all the unambiguous codons
+// with AmbBase2Bases. If all the expanded codons code the same amino acid,
+// it is returned, e.g., GCN -> A. Otherwise, ambiguityCode is returned.
+// When ambiguityCode is 0, B (D/N), Z (E/Q) or J (I/L) is returned when possible,
+// and X for others.
+func (t *CodonTable) GetDegenerate(codon []byte, allowUnknownCodon bool, ambiguityCode byte) (byte, error) {
+	aa, err := t.Get(codon, allowUnknownCodon)
+	if err != nil || aa == '-' {
+		return aa, err
+	}
+
+	var codes [3][]int
+	var bases []byte
+	var ok bool
+	var c int
+	for i := 0; i < 3; i++ {
+		if bases, ok = AmbBase2Bases[codon[i]]; !ok { // gaps
+			return 'X', nil
+		}
+		codes[i] = make([]int, 0, 4)
+		for _, b := range bases {
+			if c, _ = base2code(b); c == 1 || c == 2 || c == 4 || c == 8 {
+				codes[i] = append(codes[i], c)
+			}
+		}
+	}
+	if len(codes[0]) == 1 && len(codes[1]) == 1 && len(codes[2]) == 1 {
+		return aa, nil
+	}
+
+	aas := make([]byte, 0, 4)
+	var a byte
+	var found bool
+	for _, i := range codes[0] {
+		for _, j := range codes[1] {
+			for _, k := range codes[2] {
+				a = t.table[i][j][k]
+				if a == 0 {
+					return 'X', nil
+				}
+				found = false
+				for _, b := range aas {
+					if b == a {
+						found = true
+						break
+					}
+				}
+				if !found {
+					aas = append(aas, a)
+				}
+			}
+		}
+	}
+	if len(aas) == 1 {
+		return aas[0], nil
+	}
+	if ambiguityCode != 0 {
+		return ambiguityCode, nil
+	}
+	if len(aas) == 2 {
+		for code, _aas := range ambiguousAminoAcids {
+			if (aas[0] == _aas[0] && aas[1] == _aas[1]) || (aas[0] == _aas[1] && aas[1] == _aas[0]) {
+				return code, nil
+			}
+		}
+	}
+	return 'X', nil
+}
+
+// TranslateOptions contains the options of CodonTable.TranslateWithOptions.
+type TranslateOptions struct {
+	Trim              bool // remove all 'X' and '*' characters from the right end of the translation
+	Clean             bool // change all STOP codon positions from the '*' character to 'X'
+	AllowUnknownCodon bool // translate codons not in the codon table to 'X'
+	MarkInitCodonAsM  bool // represent initial codon at beginning as 'M'
+
+	// ExpandDegenerateCodons translates codons containing IUPAC ambiguous bases
+	// by expanding them, see CodonTable.GetDegenerate.
+	ExpandDegenerateCodons bool
+	// AmbiguityCode is the amino acid for degenerate codons coding different amino acids.
+	// 0 for B (D/N), Z (E/Q) or J (I/L) when possible, and X for others.
+	AmbiguityCode byte
+}
+
 // Translate translates a DNA/RNA sequence to amino acid sequences.
 // Available frame: 1, 2, 3, -1, -2 ,-3.
 // If option trim is true, it removes all 'X' and '*' characters from the right end of the translation.
@@ -203,83 +288,116 @@ func (t *CodonTable) Clone() CodonTable {
 // If option allowUnknownCodon is true, codons not in the codon table will be translated to 'X'.
 // If option markInitCodonAsM is true, initial codon at beginning will be represented as 'M'.
 func (t *CodonTable) Translate(sequence []byte, frame int, trim bool, clean bool, allowUnknownCodon bool, markInitCodonAsM bool) ([]byte, error) {
+	return t.TranslateWithOptions(sequence, frame, &TranslateOptions{
+		Trim:              trim,
+		Clean:             clean,
+		AllowUnknownCodon: allowUnknownCodon,
+		MarkInitCodonAsM:  markInitCodonAsM,
+	})
+}
+
+// complementNucleotide returns the complement base of a DNA/RNA base,
+// where U is complemented to A. It's used for translating the reverse
+// complement strand, where T and U are equivalent.
+func complementNucleotide(b byte) byte {
+	switch b {
+	case 'U':
+		return 'A'
+	case 'u':
+		return 'a'
+	}
+	p, _ := DNAredundant.PairLetter(b)
+	return p
+}
+
+// isInitCodon checks if a DNA/RNA codon (case insensitive) is an initiation codon.
+func (t *CodonTable) isInitCodon(codon []byte) bool {
+	var c [3]byte
+	for i, b := range codon {
+		if b >= 'a' && b <= 'z' {
+			b -= 32
+		}
+		if b == 'U' {
+			b = 'T'
+		}
+		c[i] = b
+	}
+	_, ok := t.InitCodons[string(c[:len(codon)])]
+	return ok
+}
+
+// get returns the amino acid of the codon according to the options.
+func (t *CodonTable) get(codon []byte, opt *TranslateOptions) (byte, error) {
+	if opt.ExpandDegenerateCodons {
+		return t.GetDegenerate(codon, opt.AllowUnknownCodon, opt.AmbiguityCode)
+	}
+	return t.Get(codon, opt.AllowUnknownCodon)
+}
+
+// TranslateWithOptions translates a DNA/RNA sequence to amino acid sequences
+// with options, see TranslateOptions.
+// Available frame: 1, 2, 3, -1, -2 ,-3.
+func (t *CodonTable) TranslateWithOptions(sequence []byte, frame int, opt *TranslateOptions) ([]byte, error) {
 	if len(sequence) < 3 {
 		return nil, fmt.Errorf("seq: sequence too short to translate: %d", len(sequence))
 	}
 	if frame < -3 || frame > 3 || frame == 0 {
 		return nil, fmt.Errorf("seq: invalid frame: %d. available: 1, 2, 3, -1, -2, -3", frame)
 	}
+	if opt == nil {
+		opt = &TranslateOptions{}
+	}
 	aas := make([]byte, 0, int((len(sequence)+2)/3))
 	var aa byte
 	var err error
 
 	first := true
-	var ok bool
 
+	var codon []byte
+	l := len(sequence)
 	if frame < 0 {
-		l := len(sequence)
-		codon := make([]byte, 3)
-		rc := DNA.PairLetter
-		for i := l + frame; i >= 2; i -= 3 {
-			codon[0], _ = rc(sequence[i])
-			codon[1], _ = rc(sequence[i-1])
-			codon[2], _ = rc(sequence[i-2])
-
-			aa, err = t.Get(codon, allowUnknownCodon)
-			if err != nil {
-				return nil, err
-			}
-
-			if markInitCodonAsM {
-				if first {
-					// convert amino acid of start codon to 'M'
-					if _, ok = t.InitCodons[strings.ToUpper(string(codon))]; ok {
-						aa = 'M'
-					}
-					first = false
-				} else if aa == '*' {
-					first = true
-				}
-			}
-
-			if trim && (aa == 'X' || aa == '*') {
+		codon = make([]byte, 3)
+	}
+	for i := 0; ; i += 3 {
+		if frame < 0 {
+			if l+frame-i < 2 {
 				break
 			}
-			if clean && aa == '*' {
-				aa = 'X'
+			codon[0] = complementNucleotide(sequence[l+frame-i])
+			codon[1] = complementNucleotide(sequence[l+frame-i-1])
+			codon[2] = complementNucleotide(sequence[l+frame-i-2])
+		} else {
+			if frame-1+i >= l-2 {
+				break
 			}
+			codon = sequence[frame-1+i : frame+2+i]
+		}
 
-			aas = append(aas, aa)
+		aa, err = t.get(codon, opt)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		for i := frame - 1; i < len(sequence)-2; i += 3 {
-			aa, err = t.Get(sequence[i:i+3], allowUnknownCodon)
-			if err != nil {
-				return nil, err
-			}
 
-			if markInitCodonAsM {
-				if first {
-					// convert amino acid of start codon to 'M'
-					_, ok = t.InitCodons[strings.ToUpper(string(sequence[i:i+3]))]
-					if ok {
-						aa = 'M'
-					}
-					first = false
-				} else if aa == '*' {
-					first = true
+		if opt.MarkInitCodonAsM {
+			if first {
+				// convert amino acid of start codon to 'M'
+				if t.isInitCodon(codon) {
+					aa = 'M'
 				}
+				first = false
+			} else if aa == '*' {
+				first = true
 			}
+		}
 
-			if trim && (aa == 'X' || aa == '*') {
-				break
-			}
-			if clean && aa == '*' {
-				aa = 'X'
-			}
-
-			aas = append(aas, aa)
+		if opt.Trim && (aa == 'X' || aa == '*') {
+			break
+		}
+		if opt.Clean && aa == '*' {
+			aa = 'X'
 		}
+
+		aas = append(aas, aa)
 	}
 	return aas, nil
 }
@@ -311,6 +429,8 @@ func (t *CodonTable) Translate(sequence []byte, frame int, trim bool, clean bool
 //     30: Peritrich Nuclear
 //     31: Blastocrithidia Nuclear
 //
+// Custom codon tables can be loaded from NCBI gc.prt or TSV files
+// and added with RegisterCodonTable.
 var CodonTables map[int]*CodonTable
 
 // https://www.ncbi.nlm.nih.gov/Taxonomy/taxonomyhome.html/index.cgi?chapter=cgencodes#SG1
@@ -336,7 +456,15 @@ func codonTableFromText(id int, name string, text string) *CodonTable {
 		}
 	}
 
+	t.setAmbiguousCodons()
+	return t
+}
+
+// setAmbiguousCodons fills the amino acids of codons containing ambiguous bases,
+// for those can be unambiguously translated, e.g., GCN -> A.
+func (t *CodonTable) setAmbiguousCodons() {
 	// supporting codon containing ambiguous base
+	var aa byte
 	var m map[byte][]int // aa - > bases
 	var ok bool
 	var codes, ambcodes []int
@@ -425,7 +553,6 @@ func codonTableFromText(id int, name string, text string) *CodonTable {
 			}
 		}
 	}
-	return t
 }
 
 func init() {
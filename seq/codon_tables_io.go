@@ -0,0 +1,456 @@
+package seq
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shenwei356/xopen"
+)
+
+// ErrInvalidCodonTableFile means the codon table file is not in the right format.
+var ErrInvalidCodonTableFile = errors.New("seq: invalid codon table file")
+
+// ErrDuplicatedCodonTable means a codon table of the same ID has been registered.
+var ErrDuplicatedCodonTable = errors.New("seq: duplicated codon table")
+
+// RegisterCodonTable adds a codon table to CodonTables, so it can be used
+// by all methods accepting a transl_table, e.g., Seq.Translate.
+// ErrDuplicatedCodonTable is returned if the ID exists and overwrite is false.
+//
+// CodonTables is not protected by any lock, so please register custom
+// codon tables before using them concurrently.
+func RegisterCodonTable(t *CodonTable, overwrite bool) error {
+	if t == nil {
+		return fmt.Errorf("seq: nil codon table")
+	}
+	if _, ok := CodonTables[t.ID]; ok && !overwrite {
+		return fmt.Errorf("%w: %d", ErrDuplicatedCodonTable, t.ID)
+	}
+	CodonTables[t.ID] = t
+	return nil
+}
+
+// codonTableFromNCBI creates a codon table from the amino acids (ncbieaa) and
+// start/stop markers (sncbieaa) of the 64 codons in the order of NCBI codon tables,
+// i.e., TTT, TTC, TTA, TTG, TCT, ... GGG.
+func codonTableFromNCBI(id int, name string, aas string, starts string) (*CodonTable, error) {
+	if len(aas) != 64 || len(starts) != 64 {
+		return nil, fmt.Errorf("%w: length of ncbieaa and sncbieaa should be 64", ErrInvalidCodonTableFile)
+	}
+	t := NewCodonTable(id, name)
+	var aa, start byte
+	var codon []byte
+	for i := 0; i < 64; i++ {
+		aa, start = aas[i], starts[i]
+		if !(aa >= 'A' && aa <= 'Z' || aa == '*') {
+			return nil, fmt.Errorf("%w: invalid amino acid: %c", ErrInvalidCodonTableFile, aa)
+		}
+		codon = index2codon(i)
+		t.Set(codon, aa)
+		switch start {
+		case 'M':
+			t.InitCodons[string(codon)] = struct{}{}
+		case '*':
+			t.StopCodons[string(codon)] = struct{}{}
+		case '-':
+		default:
+			return nil, fmt.Errorf("%w: invalid start/stop marker: %c", ErrInvalidCodonTableFile, start)
+		}
+	}
+	t.setAmbiguousCodons()
+	return t, nil
+}
+
+// ncbieaa returns the amino acids and start/stop markers of the 64 codons
+// in the order of NCBI codon tables.
+func (t *CodonTable) ncbieaa() (aas []byte, starts []byte) {
+	aas = make([]byte, 64)
+	starts = make([]byte, 64)
+	var codon []byte
+	var ok bool
+	for i := 0; i < 64; i++ {
+		codon = index2codon(i)
+		aas[i], _ = t.Get(codon, true)
+		if _, ok = t.InitCodons[string(codon)]; ok {
+			starts[i] = 'M'
+		} else if _, ok = t.StopCodons[string(codon)]; ok {
+			starts[i] = '*'
+		} else {
+			starts[i] = '-'
+		}
+	}
+	return aas, starts
+}
+
+// ------------------------------------------------------------------------
+// NCBI gc.prt
+
+/*
+ReadCodonTablesGCPrt reads codon tables in the ASN.1 format of NCBI gc.prt
+(https://ftp.ncbi.nih.gov/entrez/misc/data/gc.prt):
+
+	Genetic-code-table ::= {
+	 {
+	  name "Standard" ,
+	  name "SGC0" ,
+	  id 1 ,
+	  ncbieaa  "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	  sncbieaa "---M------**--*----M---------------M----------------------------"
+	  -- Base1  TTTTTTTTTTTTTTTTCCCCCCCCCCCCCCCCAAAAAAAAAAAAAAAAGGGGGGGGGGGGGGGG
+	  -- Base2  TTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGG
+	  -- Base3  TCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAG
+	 },
+	 ...
+	}
+
+Only the first name of each table is kept, and names spanning multiple lines
+are joined. The returned tables are not registered, see RegisterCodonTable.
+*/
+func ReadCodonTablesGCPrt(r io.Reader) ([]*CodonTable, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := gcprtTokens(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]*CodonTable, 0, 32)
+	var depth int
+	var name, aas, starts, key string
+	var id int
+	var hasID bool
+	var t *CodonTable
+	for i := 0; i < len(tokens); i++ {
+		switch tok := tokens[i]; {
+		case tok.text == "{" && !tok.quoted:
+			depth++
+			if depth == 2 {
+				name, aas, starts, id, hasID = "", "", "", 0, false
+			}
+		case tok.text == "}" && !tok.quoted:
+			if depth == 2 {
+				if !hasID {
+					return nil, fmt.Errorf("%w: id missing for table: %s", ErrInvalidCodonTableFile, name)
+				}
+				t, err = codonTableFromNCBI(id, name, aas, starts)
+				if err != nil {
+					return nil, fmt.Errorf("%w (table %d)", err, id)
+				}
+				tables = append(tables, t)
+			}
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: unbalanced braces", ErrInvalidCodonTableFile)
+			}
+		case tok.text == "," && !tok.quoted:
+		case depth == 2:
+			key = tok.text
+			i++
+			if i >= len(tokens) {
+				return nil, fmt.Errorf("%w: value missing for %s", ErrInvalidCodonTableFile, key)
+			}
+			tok = tokens[i]
+			switch key {
+			case "name":
+				if name == "" {
+					name = tok.text
+				}
+			case "id":
+				id, err = strconv.Atoi(tok.text)
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid id: %s", ErrInvalidCodonTableFile, tok.text)
+				}
+				hasID = true
+			case "ncbieaa":
+				aas = tok.text
+			case "sncbieaa":
+				starts = tok.text
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced braces", ErrInvalidCodonTableFile)
+	}
+	return tables, nil
+}
+
+// ReadCodonTablesGCPrtFromFile reads codon tables from a gc.prt file, "-" for stdin.
+func ReadCodonTablesGCPrtFromFile(file string) ([]*CodonTable, error) {
+	fh, err := xopen.Ropen(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return ReadCodonTablesGCPrt(fh)
+}
+
+type gcprtToken struct {
+	text   string
+	quoted bool
+}
+
+var reWhiteSpaces = regexp.MustCompile(`\s+`)
+
+// gcprtTokens splits gc.prt into tokens: braces, commas, words and quoted strings.
+// Comments starting with "--" are removed.
+func gcprtTokens(data []byte) ([]gcprtToken, error) {
+	tokens := make([]gcprtToken, 0, 1024)
+	var b byte
+	var j int
+	for i := 0; i < len(data); i++ {
+		b = data[i]
+		switch {
+		case b == ' ' || b == '\t' || b == '\r' || b == '\n':
+		case b == '-' && i+1 < len(data) && data[i+1] == '-':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case b == '{' || b == '}' || b == ',':
+			tokens = append(tokens, gcprtToken{text: string(b)})
+		case b == '"':
+			var buf bytes.Buffer
+			for j = i + 1; j < len(data); j++ {
+				if data[j] == '"' {
+					if j+1 < len(data) && data[j+1] == '"' { // escaped quote
+						buf.WriteByte('"')
+						j++
+						continue
+					}
+					break
+				}
+				buf.WriteByte(data[j])
+			}
+			if j == len(data) {
+				return nil, fmt.Errorf("%w: unclosed quote", ErrInvalidCodonTableFile)
+			}
+			tokens = append(tokens, gcprtToken{
+				text:   reWhiteSpaces.ReplaceAllString(buf.String(), " "),
+				quoted: true,
+			})
+			i = j
+		default:
+			for j = i; j < len(data); j++ {
+				b = data[j]
+				if b == ' ' || b == '\t' || b == '\r' || b == '\n' ||
+					b == '{' || b == '}' || b == ',' || b == '"' {
+					break
+				}
+			}
+			tokens = append(tokens, gcprtToken{text: string(data[i:j])})
+			i = j - 1
+		}
+	}
+	return tokens, nil
+}
+
+// WriteCodonTablesGCPrt writes codon tables in the format of NCBI gc.prt,
+// which can be read by ReadCodonTablesGCPrt.
+func WriteCodonTablesGCPrt(w io.Writer, tables ...*CodonTable) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("Genetic-code-table ::= {\n")
+	var aas, starts []byte
+	for i, t := range tables {
+		aas, starts = t.ncbieaa()
+		bw.WriteString(" {\n")
+		fmt.Fprintf(bw, "  name \"%s\" ,\n", strings.ReplaceAll(t.Name, `"`, `""`))
+		fmt.Fprintf(bw, "  id %d ,\n", t.ID)
+		fmt.Fprintf(bw, "  ncbieaa  \"%s\",\n", aas)
+		fmt.Fprintf(bw, "  sncbieaa \"%s\"\n", starts)
+		for j := 0; j < 3; j++ {
+			fmt.Fprintf(bw, "  -- Base%d  ", j+1)
+			for k := 0; k < 64; k++ {
+				bw.WriteByte(index2codon(k)[j])
+			}
+			bw.WriteByte('\n')
+		}
+		if i < len(tables)-1 {
+			bw.WriteString(" },\n")
+		} else {
+			bw.WriteString(" }\n")
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// ------------------------------------------------------------------------
+// TSV
+
+/*
+ReadCodonTableTSV reads a codon table in tab-delimited format,
+in the same layout as codon_table_standard.tsv:
+
+	b1	b2	b3	aa	m
+	T	T	T	F	-
+	T	T	C	F	-
+	T	T	A	L	-
+	T	T	G	L	M
+	...
+
+where m is M for initiation codons, * for stop codons, and - for others.
+Columns are located by the header line, and all the 64 codons are required.
+The returned table is not registered, see RegisterCodonTable.
+*/
+func ReadCodonTableTSV(r io.Reader, id int, name string) (*CodonTable, error) {
+	scanner := bufio.NewScanner(r)
+	var line string
+	var items []string
+	cols := map[string]int{"b1": -1, "b2": -1, "b3": -1, "aa": -1, "m": -1}
+	var header bool
+	var maxCol int
+	var codon []byte
+	var idx int
+	var ok bool
+	var seen [64]bool
+	aas := make([]byte, 64)
+	starts := make([]byte, 64)
+	for scanner.Scan() {
+		line = strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		items = strings.Split(line, "\t")
+		for i, item := range items {
+			items[i] = strings.TrimSpace(item)
+		}
+		if !header {
+			for i, item := range items {
+				if _, ok = cols[item]; ok {
+					cols[item] = i
+				}
+			}
+			for col, i := range cols {
+				if i < 0 {
+					return nil, fmt.Errorf("%w: column not found: %s", ErrInvalidCodonTableFile, col)
+				}
+				if i > maxCol {
+					maxCol = i
+				}
+			}
+			header = true
+			continue
+		}
+		if len(items) <= maxCol {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCodonTableFile, line)
+		}
+		codon = []byte(items[cols["b1"]] + items[cols["b2"]] + items[cols["b3"]])
+		idx, ok = codonIndex(codon)
+		if !ok || len(items[cols["aa"]]) != 1 || len(items[cols["m"]]) != 1 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCodonTableFile, line)
+		}
+		if seen[idx] {
+			return nil, fmt.Errorf("%w: duplicated codon: %s", ErrInvalidCodonTableFile, codon)
+		}
+		seen[idx] = true
+		aas[idx] = items[cols["aa"]][0]
+		starts[idx] = items[cols["m"]][0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("%w: no header line", ErrInvalidCodonTableFile)
+	}
+	for i, s := range seen {
+		if !s {
+			return nil, fmt.Errorf("%w: codon missing: %s", ErrInvalidCodonTableFile, index2codon(i))
+		}
+	}
+	return codonTableFromNCBI(id, name, string(aas), string(starts))
+}
+
+// ReadCodonTableTSVFromFile reads a codon table from a TSV file, "-" for stdin.
+func ReadCodonTableTSVFromFile(file string, id int, name string) (*CodonTable, error) {
+	fh, err := xopen.Ropen(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return ReadCodonTableTSV(fh, id, name)
+}
+
+// WriteTSV writes the codon table in tab-delimited format, which can be
+// read by ReadCodonTableTSV.
+func (t *CodonTable) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	aas, starts := t.ncbieaa()
+	var codon []byte
+	bw.WriteString("b1\tb2\tb3\taa\tm\n")
+	for i := 0; i < 64; i++ {
+		codon = index2codon(i)
+		fmt.Fprintf(bw, "%c\t%c\t%c\t%c\t%c\n", codon[0], codon[1], codon[2], aas[i], starts[i])
+	}
+	return bw.Flush()
+}
+
+// ------------------------------------------------------------------------
+// output of CodonTable.String()
+
+var reCodonTableTitle = regexp.MustCompile(`^(.*) \(transl_table=(\d+)\)$`)
+
+// ParseCodonTable parses the output of CodonTable.String() or
+// CodonTable.StringWithAmbiguousCodons(), i.e., it's the inverse of them.
+func ParseCodonTable(text string) (*CodonTable, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	m := reCodonTableTitle.FindStringSubmatch(lines[0])
+	if m == nil {
+		return nil, fmt.Errorf("%w: invalid title line: %s", ErrInvalidCodonTableFile, lines[0])
+	}
+	id, _ := strconv.Atoi(m[2])
+	t := NewCodonTable(id, m[1])
+
+	var section, line string
+	var items []string
+	var aa byte
+	var err error
+	for _, line = range lines[1:] {
+		switch line {
+		case "Initiation Codons:", "Stop Codons:", "Stranslate Table:":
+			section = line
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || section == "" {
+			continue
+		}
+		for _, item := range strings.Split(line, ", ") {
+			switch section {
+			case "Initiation Codons:":
+				t.InitCodons[strings.ToUpper(item)] = struct{}{}
+			case "Stop Codons:":
+				t.StopCodons[strings.ToUpper(item)] = struct{}{}
+			default:
+				items = strings.Split(item, ": ")
+				if len(items) != 2 || len(items[1]) != 1 {
+					return nil, fmt.Errorf("%w: invalid codon: %s", ErrInvalidCodonTableFile, item)
+				}
+				aa = items[1][0]
+				if err = t.Set2(items[0], aa); err != nil {
+					return nil, fmt.Errorf("%w: invalid codon: %s", ErrInvalidCodonTableFile, item)
+				}
+			}
+		}
+	}
+
+	missing := make([]string, 0, 8)
+	for i := 0; i < 64; i++ {
+		if aa, _ = t.Get(index2codon(i), true); aa == 'X' {
+			missing = append(missing, string(index2codon(i)))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: codon missing: %s", ErrInvalidCodonTableFile, strings.Join(missing, ", "))
+	}
+	t.setAmbiguousCodons()
+	return t, nil
+}
@@ -0,0 +1,145 @@
+package seq
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var gcprtTest = `--**************************************************************************
+--  This is the NCBI genetic code table
+--**************************************************************************
+Genetic-code-table ::= {
+ {
+  name "Standard" ,
+  name "SGC0" ,
+  id 1 ,
+  ncbieaa  "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+  sncbieaa "---M------**--*----M---------------M----------------------------"
+  -- Base1  TTTTTTTTTTTTTTTTCCCCCCCCCCCCCCCCAAAAAAAAAAAAAAAAGGGGGGGGGGGGGGGG
+  -- Base2  TTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGG
+  -- Base3  TCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAG
+ },
+ {
+  name "Mold Mitochondrial; Protozoan Mitochondrial; Coelenterate
+ Mitochondrial; Mycoplasma; Spiroplasma" ,
+  name "SGC3" ,
+  id 4 ,
+  ncbieaa  "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+  sncbieaa "--MM------**-------M------------MMMM---------------M------------"
+  -- Base1  TTTTTTTTTTTTTTTTCCCCCCCCCCCCCCCCAAAAAAAAAAAAAAAAGGGGGGGGGGGGGGGG
+  -- Base2  TTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGG
+  -- Base3  TCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAG
+ }
+}
+`
+
+func TestReadCodonTablesGCPrt(t *testing.T) {
+	tables, err := ReadCodonTablesGCPrt(strings.NewReader(gcprtTest))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(tables) != 2 {
+		t.Errorf("number of tables error: %d", len(tables))
+		return
+	}
+	if tables[1].Name != "Mold Mitochondrial; Protozoan Mitochondrial; Coelenterate Mitochondrial; Mycoplasma; Spiroplasma" {
+		t.Errorf("table name error: %s", tables[1].Name)
+	}
+	for _, table := range tables {
+		table.Name = CodonTables[table.ID].Name
+		if table.StringWithAmbiguousCodons() != CodonTables[table.ID].StringWithAmbiguousCodons() {
+			t.Errorf("table %d differs from the built-in one", table.ID)
+		}
+	}
+
+	_, err = ReadCodonTablesGCPrt(strings.NewReader(strings.Replace(gcprtTest, "MTTTTNN", "MTTTTN", 1)))
+	if !errors.Is(err, ErrInvalidCodonTableFile) {
+		t.Errorf("invalid ncbieaa should be reported")
+	}
+}
+
+func TestCodonTableRoundTrip(t *testing.T) {
+	tables := make([]*CodonTable, 0, len(CodonTables))
+	for _, table := range CodonTables {
+		tables = append(tables, table)
+	}
+
+	// gc.prt
+	var buf bytes.Buffer
+	if err := WriteCodonTablesGCPrt(&buf, tables...); err != nil {
+		t.Error(err)
+		return
+	}
+	tables2, err := ReadCodonTablesGCPrt(&buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(tables2) != len(tables) {
+		t.Errorf("number of tables error: %d", len(tables2))
+		return
+	}
+	for i, table := range tables2 {
+		if table.StringWithAmbiguousCodons() != tables[i].StringWithAmbiguousCodons() {
+			t.Errorf("gc.prt round trip error: table %d", table.ID)
+		}
+	}
+
+	var table2 *CodonTable
+	for _, table := range tables {
+		// TSV
+		buf.Reset()
+		table.WriteTSV(&buf)
+		table2, err = ReadCodonTableTSV(&buf, table.ID, table.Name)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if table2.StringWithAmbiguousCodons() != table.StringWithAmbiguousCodons() {
+			t.Errorf("TSV round trip error: table %d", table.ID)
+		}
+
+		// String()
+		table2, err = ParseCodonTable(table.String())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if table2.StringWithAmbiguousCodons() != table.StringWithAmbiguousCodons() {
+			t.Errorf("String() round trip error: table %d", table.ID)
+		}
+	}
+}
+
+func TestRegisterCodonTable(t *testing.T) {
+	table, err := ReadCodonTableTSVFromFile("codon_table_standard.tsv", 1001, "Custom Code")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	table.Set2("TGA", 'W')
+	delete(table.StopCodons, "TGA")
+
+	if err = RegisterCodonTable(table, false); err != nil {
+		t.Error(err)
+		return
+	}
+	defer delete(CodonTables, 1001)
+
+	if err = RegisterCodonTable(table, false); !errors.Is(err, ErrDuplicatedCodonTable) {
+		t.Errorf("duplicated codon table should be reported")
+	}
+
+	s, _ := NewSeq(DNA, []byte("ATGTGATAA"))
+	p, err := s.Translate(1001, 1, false, false, false, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(p.Seq) != "MW*" {
+		t.Errorf("translation with custom codon table error: %s", p.Seq)
+	}
+}
@@ -1,7 +1,10 @@
 package seq
 
 import (
+	"bufio"
+	"os"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -150,3 +153,78 @@ func TestCodonTableStranslation(t *testing.T) {
 		}
 	}
 }
+
+func TestGetDegenerate(t *testing.T) {
+	table := CodonTables[1]
+
+	// all degenerate codons listed in codon_table_standard.amb.tsv
+	fh, err := os.Open("codon_table_standard.amb.tsv")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer fh.Close()
+	scanner := bufio.NewScanner(fh)
+	var items []string
+	var aa byte
+	for scanner.Scan() {
+		items = strings.Fields(scanner.Text())
+		if len(items) != 2 {
+			continue
+		}
+		aa, err = table.GetDegenerate([]byte(items[0]), false, 0)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if aa != items[1][0] {
+			t.Errorf("degenerate codon %s: expected %s, returned %c", items[0], items[1], aa)
+		}
+	}
+
+	for codon, expected := range map[string]byte{
+		"RAY": 'B', // GAY (D) and AAY (N)
+		"SAR": 'Z', // CAR (Q) and GAR (E)
+		"MTH": 'J', // ATH (I) and CTH (L)
+		"YTN": 'X', // TTY (F) and others (L)
+		"TRA": '*',
+		"GcN": 'A',
+		"ACU": 'T',
+		"A-G": 'X',
+		"---": '-',
+	} {
+		aa, _ = table.GetDegenerate([]byte(codon), false, 0)
+		if aa != expected {
+			t.Errorf("degenerate codon %s: expected %c, returned %c", codon, expected, aa)
+		}
+	}
+
+	if aa, _ = table.GetDegenerate([]byte("RAY"), false, 'X'); aa != 'X' {
+		t.Errorf("custom ambiguity code error: %c", aa)
+	}
+}
+
+func TestTranslateDegenerateCodons(t *testing.T) {
+	s, _ := NewSeq(DNAredundant, []byte("ATGGCNRAYYTNTRA"))
+	opt := &TranslateOptions{ExpandDegenerateCodons: true}
+	p, err := s.TranslateWithOptions(1, 1, opt)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(p.Seq) != "MABX*" {
+		t.Errorf("translation with degenerate codons error: %s", p.Seq)
+	}
+
+	// the reverse complement strand: TYANARRTYNGCCAT
+	p, _ = s.TranslateWithOptions(1, -1, opt)
+	if string(p.Seq) != "XXXXH" {
+		t.Errorf("translation with degenerate codons error: %s", p.Seq)
+	}
+
+	opt.AmbiguityCode = 'X'
+	p, _ = s.TranslateWithOptions(1, 1, opt)
+	if string(p.Seq) != "MAXX*" {
+		t.Errorf("translation with degenerate codons error: %s", p.Seq)
+	}
+}
@@ -0,0 +1,469 @@
+package seq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shenwei356/xopen"
+)
+
+// ErrInvalidCodonUsageFile means the codon usage file is not in the right format.
+var ErrInvalidCodonUsageFile = errors.New("seq: invalid codon usage file")
+
+// nucleotides in the order of NCBI codon tables
+var ncbiBases = [4]byte{'T', 'C', 'A', 'G'}
+
+// ncbiBase2idx maps T/U C A G (case insensitive) to 0-3, others to 4.
+var ncbiBase2idx = [256]int{}
+
+func init() {
+	for i := range ncbiBase2idx {
+		ncbiBase2idx[i] = 4
+	}
+	for i, b := range ncbiBases {
+		ncbiBase2idx[b] = i
+		ncbiBase2idx[b+32] = i
+	}
+	ncbiBase2idx['U'] = 0
+	ncbiBase2idx['u'] = 0
+}
+
+// codonIndex returns the index (0-63) of a codon in the order of NCBI codon tables,
+// i.e., TTT, TTC, TTA, TTG, TCT, ... GGG. Codons with bases other than
+// A/C/G/T/U are reported with false.
+func codonIndex(codon []byte) (int, bool) {
+	if len(codon) != 3 {
+		return 0, false
+	}
+	i, j, k := ncbiBase2idx[codon[0]], ncbiBase2idx[codon[1]], ncbiBase2idx[codon[2]]
+	if i == 4 || j == 4 || k == 4 {
+		return 0, false
+	}
+	return i<<4 | j<<2 | k, true
+}
+
+// index2codon returns the codon of an index.
+func index2codon(i int) []byte {
+	return []byte{ncbiBases[i>>4&3], ncbiBases[i>>2&3], ncbiBases[i&3]}
+}
+
+// CodonUsage holds the codon counts of coding sequences (CDS).
+type CodonUsage struct {
+	Table *CodonTable
+
+	counts  [64]float64
+	aas     [64]byte // amino acids of the 64 codons
+	skipped int      // number of skipped ambiguous codons
+}
+
+// NewCodonUsage creates a CodonUsage for a codon table.
+func NewCodonUsage(table *CodonTable) *CodonUsage {
+	u := &CodonUsage{Table: table}
+	for i := 0; i < 64; i++ {
+		u.aas[i], _ = table.Get(index2codon(i), true)
+	}
+	return u
+}
+
+// Add counts codons of a CDS in the given frame: 1, 2, 3, -1, -2, -3.
+// Codons containing bases other than A/C/G/T/U are skipped,
+// the number of which can be obtained by Skipped().
+func (u *CodonUsage) Add(cds []byte, frame int) error {
+	if frame < -3 || frame > 3 || frame == 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidFrame, frame)
+	}
+	var s []byte
+	if frame < 0 {
+		s = make([]byte, len(cds))
+		for i, b := range cds {
+			s[len(cds)-1-i] = complementNucleotide(b)
+		}
+		frame = -frame
+	} else {
+		s = cds
+	}
+
+	var idx int
+	var ok bool
+	for i := frame - 1; i+3 <= len(s); i += 3 {
+		idx, ok = codonIndex(s[i : i+3])
+		if !ok {
+			u.skipped++
+			continue
+		}
+		u.counts[idx]++
+	}
+	return nil
+}
+
+// AddCodon adds n counts to a codon.
+func (u *CodonUsage) AddCodon(codon []byte, n float64) error {
+	idx, ok := codonIndex(codon)
+	if !ok {
+		return ErrUnknownCodon
+	}
+	u.counts[idx] += n
+	return nil
+}
+
+// Merge adds counts of another CodonUsage.
+func (u *CodonUsage) Merge(other *CodonUsage) {
+	for i, c := range other.counts {
+		u.counts[i] += c
+	}
+	u.skipped += other.skipped
+}
+
+// Count returns the count of a codon.
+func (u *CodonUsage) Count(codon []byte) float64 {
+	idx, ok := codonIndex(codon)
+	if !ok {
+		return 0
+	}
+	return u.counts[idx]
+}
+
+// Total returns the total count of all codons.
+func (u *CodonUsage) Total() float64 {
+	var sum float64
+	for _, c := range u.counts {
+		sum += c
+	}
+	return sum
+}
+
+// Skipped returns the number of skipped ambiguous codons.
+func (u *CodonUsage) Skipped() int {
+	return u.skipped
+}
+
+// synonymous returns the total count and number of synonymous codons of an amino acid.
+func (u *CodonUsage) synonymous(aa byte) (float64, int) {
+	var sum float64
+	var n int
+	for i := 0; i < 64; i++ {
+		if u.aas[i] == aa {
+			sum += u.counts[i]
+			n++
+		}
+	}
+	return sum, n
+}
+
+// RSCU returns the relative synonymous codon usage of all 64 codons, in the
+// order of NCBI codon tables, i.e., TTT, TTC, TTA, TTG, TCT, ... GGG.
+// RSCU = observed count / (total count of the amino acid / number of synonymous codons).
+// Amino acids not observed have RSCU of 0.
+func (u *CodonUsage) RSCU() []float64 {
+	rscu := make([]float64, 64)
+	var sums [256]float64
+	var ns [256]int
+	for i := 0; i < 64; i++ {
+		sums[u.aas[i]] += u.counts[i]
+		ns[u.aas[i]]++
+	}
+	var aa byte
+	for i := 0; i < 64; i++ {
+		aa = u.aas[i]
+		if sums[aa] == 0 {
+			continue
+		}
+		rscu[i] = u.counts[i] * float64(ns[aa]) / sums[aa]
+	}
+	return rscu
+}
+
+// RSCUOf returns the RSCU of a codon.
+func (u *CodonUsage) RSCUOf(codon []byte) float64 {
+	idx, ok := codonIndex(codon)
+	if !ok {
+		return 0
+	}
+	sum, n := u.synonymous(u.aas[idx])
+	if sum == 0 {
+		return 0
+	}
+	return u.counts[idx] * float64(n) / sum
+}
+
+// CAIPseudoCount is the count assigned to codons absent in the reference
+// usage table when computing relative adaptiveness for CAI.
+var CAIPseudoCount = 0.5
+
+// Weights returns the relative adaptiveness (w) of all 64 codons, used in CAI.
+// w = RSCU / max RSCU of the synonymous codons.
+// Absent codons are given a count of CAIPseudoCount.
+func (u *CodonUsage) Weights() []float64 {
+	w := make([]float64, 64)
+	var maxes [256]float64
+	var c float64
+	for i := 0; i < 64; i++ {
+		c = u.counts[i]
+		if c > maxes[u.aas[i]] {
+			maxes[u.aas[i]] = c
+		}
+	}
+	var aa byte
+	for i := 0; i < 64; i++ {
+		aa = u.aas[i]
+		if maxes[aa] == 0 {
+			continue
+		}
+		c = u.counts[i]
+		if c == 0 {
+			c = CAIPseudoCount
+		}
+		w[i] = c / maxes[aa]
+	}
+	return w
+}
+
+/*
+CAI computes the codon adaptation index of a CDS in the given frame,
+using this CodonUsage as the reference table.
+Stop codons, ambiguous codons and codons of amino acids with only one
+codon (e.g., ATG and TGG in the standard code) are excluded.
+
+Reference:
+
+ 1. Sharp PM, Li WH. The codon Adaptation Index - a measure of directional
+    synonymous codon usage bias, and its potential applications. NAR. 1987.
+*/
+func (u *CodonUsage) CAI(cds []byte, frame int) (float64, error) {
+	q := NewCodonUsage(u.Table)
+	if err := q.Add(cds, frame); err != nil {
+		return 0, err
+	}
+	w := u.Weights()
+
+	var sum, n float64
+	var aa byte
+	var nSyn [256]int
+	for i := 0; i < 64; i++ {
+		nSyn[u.aas[i]]++
+	}
+	for i := 0; i < 64; i++ {
+		aa = u.aas[i]
+		if q.counts[i] == 0 || aa == '*' || nSyn[aa] < 2 || w[i] == 0 {
+			continue
+		}
+		sum += q.counts[i] * math.Log(w[i])
+		n += q.counts[i]
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return math.Exp(sum / n), nil
+}
+
+// GC3 returns the GC content at the third positions of all sense codons.
+func (u *CodonUsage) GC3() float64 {
+	var gc, sum float64
+	var b byte
+	for i := 0; i < 64; i++ {
+		if u.aas[i] == '*' {
+			continue
+		}
+		sum += u.counts[i]
+		b = ncbiBases[i&3]
+		if b == 'G' || b == 'C' {
+			gc += u.counts[i]
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	return gc / sum
+}
+
+/*
+ENc computes the effective number of codons (Nc).
+
+Amino acids are grouped by the number of synonymous codons (k).
+For each amino acid with n > 1 observed codons, the homozygosity is
+F = (n*sum(p_i^2) - 1) / (n - 1). Nc is the sum over all groups of
+the number of amino acids divided by the average F of the group.
+A missing F of the 3-fold group is the average of those of the 2- and 4-fold groups,
+and F of other missing groups is 1/k. The result is capped to the number of sense codons.
+
+Reference:
+
+ 1. Wright F. The 'effective number of codons' used in a gene. Gene. 1990.
+*/
+func (u *CodonUsage) ENc() float64 {
+	var sums [256]float64
+	var ns [256]int
+	for i := 0; i < 64; i++ {
+		if u.aas[i] == '*' {
+			continue
+		}
+		sums[u.aas[i]] += u.counts[i]
+		ns[u.aas[i]]++
+	}
+
+	var nAAs [65]int      // number of amino acids of a degeneracy class
+	var sumFs [65]float64 // sum of F of a degeneracy class
+	var nFs [65]int       // number of F of a degeneracy class
+	var nSense int
+	var p, sp, n float64
+	for aa := 0; aa < 256; aa++ {
+		k := ns[aa]
+		if k == 0 {
+			continue
+		}
+		nSense += k
+		nAAs[k]++
+		n = sums[aa]
+		if k == 1 || n <= 1 {
+			continue
+		}
+		sp = 0
+		for i := 0; i < 64; i++ {
+			if int(u.aas[i]) == aa {
+				p = u.counts[i] / n
+				sp += p * p
+			}
+		}
+		sumFs[k] += (n*sp - 1) / (n - 1)
+		nFs[k]++
+	}
+
+	avgF := func(k int) (float64, bool) {
+		if nFs[k] == 0 || sumFs[k] <= 0 {
+			return 0, false
+		}
+		return sumFs[k] / float64(nFs[k]), true
+	}
+
+	var nc, f float64
+	var ok bool
+	for k := 1; k <= 64; k++ {
+		if nAAs[k] == 0 {
+			continue
+		}
+		if k == 1 {
+			nc += float64(nAAs[k])
+			continue
+		}
+		f, ok = avgF(k)
+		if !ok {
+			f2, ok2 := avgF(2)
+			f4, ok4 := avgF(4)
+			if k == 3 && ok2 && ok4 {
+				f = (f2 + f4) / 2
+			} else {
+				f = 1 / float64(k)
+			}
+		}
+		nc += float64(nAAs[k]) / f
+	}
+	if nc > float64(nSense) {
+		nc = float64(nSense)
+	}
+	return nc
+}
+
+/*
+Write outputs the codon usage in tab-delimited format, in the same layout
+as codon_table_standard.tsv:
+
+	b1	b2	b3	aa	count	rscu
+	T	T	T	F	12	0.800
+	...
+*/
+func (u *CodonUsage) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	rscu := u.RSCU()
+	var codon []byte
+	bw.WriteString("b1\tb2\tb3\taa\tcount\trscu\n")
+	for i := 0; i < 64; i++ {
+		codon = index2codon(i)
+		fmt.Fprintf(bw, "%c\t%c\t%c\t%c\t%s\t%.3f\n", codon[0], codon[1], codon[2], u.aas[i],
+			strconv.FormatFloat(u.counts[i], 'f', -1, 64), rscu[i])
+	}
+	return bw.Flush()
+}
+
+// WriteToFile writes the codon usage to a file, "-" for stdout.
+func (u *CodonUsage) WriteToFile(file string) error {
+	outfh, err := xopen.Wopen(file)
+	if err != nil {
+		return err
+	}
+	defer outfh.Close()
+	return u.Write(outfh)
+}
+
+// ReadCodonUsage reads a codon usage table outputted by Write.
+// Columns are located by the header line, only b1, b2, b3 and count are required.
+// The amino acids are determined by the given codon table.
+func ReadCodonUsage(r io.Reader, table *CodonTable) (*CodonUsage, error) {
+	u := NewCodonUsage(table)
+
+	scanner := bufio.NewScanner(r)
+	var line string
+	var items []string
+	cols := map[string]int{"b1": -1, "b2": -1, "b3": -1, "count": -1}
+	var header bool
+	var c float64
+	var err error
+	var codon []byte
+	var maxCol int
+	for scanner.Scan() {
+		line = strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		items = strings.Split(line, "\t")
+		if !header {
+			for i, item := range items {
+				if _, ok := cols[item]; ok {
+					cols[item] = i
+				}
+			}
+			for col, i := range cols {
+				if i < 0 {
+					return nil, fmt.Errorf("%w: column not found: %s", ErrInvalidCodonUsageFile, col)
+				}
+				if i > maxCol {
+					maxCol = i
+				}
+			}
+			header = true
+			continue
+		}
+		if len(items) <= maxCol {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCodonUsageFile, line)
+		}
+		codon = []byte(items[cols["b1"]] + items[cols["b2"]] + items[cols["b3"]])
+		c, err = strconv.ParseFloat(items[cols["count"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCodonUsageFile, line)
+		}
+		if err = u.AddCodon(codon, c); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCodonUsageFile, line)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("%w: no header line", ErrInvalidCodonUsageFile)
+	}
+	return u, nil
+}
+
+// ReadCodonUsageFromFile reads a codon usage table from a file, "-" for stdin.
+func ReadCodonUsageFromFile(file string, table *CodonTable) (*CodonUsage, error) {
+	fh, err := xopen.Ropen(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return ReadCodonUsage(fh, table)
+}
@@ -0,0 +1,85 @@
+package seq
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestCodonUsage(t *testing.T) {
+	u := NewCodonUsage(CodonTables[1])
+	if err := u.Add([]byte("ATGGCTGCCGCAGCGNNNTAA"), 1); err != nil {
+		t.Error(err)
+		return
+	}
+	if u.Total() != 6 || u.Skipped() != 1 {
+		t.Errorf("codon counting error: total: %f, skipped: %d", u.Total(), u.Skipped())
+	}
+	for _, codon := range []string{"GCT", "GCC", "GCA", "GCG"} {
+		if u.RSCUOf([]byte(codon)) != 1 {
+			t.Errorf("RSCU error: %s: %f", codon, u.RSCUOf([]byte(codon)))
+		}
+	}
+	if u.GC3() != 0.6 { // G, T, C, A, G
+		t.Errorf("GC3 error: %f", u.GC3())
+	}
+
+	// frame and strand
+	u2 := NewCodonUsage(CodonTables[1])
+	u2.Add([]byte("TTACGCTGCGGCAGCCATC"), -2)
+	if u2.Count([]byte("GCT")) != 1 || u2.Count([]byte("ATG")) != 1 || u2.Total() != 6 {
+		t.Errorf("codon counting error for negative frame")
+	}
+
+	// CAI
+	ref := NewCodonUsage(CodonTables[1])
+	ref.AddCodon([]byte("GCT"), 10)
+	cai, err := ref.CAI([]byte("ATGGCTGCCTAA"), 1)
+	if err != nil {
+		t.Error(err)
+	}
+	if math.Abs(cai-math.Sqrt(CAIPseudoCount/10)) > 1e-9 {
+		t.Errorf("CAI error: %f", cai)
+	}
+
+	// write and read
+	var buf bytes.Buffer
+	if err = u.Write(&buf); err != nil {
+		t.Error(err)
+	}
+	u3, err := ReadCodonUsage(&buf, CodonTables[1])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if u3.Total() != u.Total() || u3.Count([]byte("GCG")) != 1 {
+		t.Errorf("reading codon usage error")
+	}
+}
+
+func TestENc(t *testing.T) {
+	// uniform usage
+	u := NewCodonUsage(CodonTables[1])
+	for i := 0; i < 64; i++ {
+		u.AddCodon(index2codon(i), 100)
+	}
+	if nc := u.ENc(); math.Abs(nc-61) > 1 {
+		t.Errorf("ENc error for uniform usage: %f", nc)
+	}
+
+	// extreme bias, one codon per amino acid
+	u = NewCodonUsage(CodonTables[1])
+	seen := make(map[byte]bool)
+	var aa byte
+	for i := 0; i < 64; i++ {
+		aa, _ = CodonTables[1].Get(index2codon(i), false)
+		if aa == '*' || seen[aa] {
+			continue
+		}
+		seen[aa] = true
+		u.AddCodon(index2codon(i), 100)
+	}
+	if nc := u.ENc(); math.Abs(nc-20) > 0.01 {
+		t.Errorf("ENc error for extremely biased usage: %f", nc)
+	}
+}
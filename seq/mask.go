@@ -0,0 +1,539 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ErrInvalidInterval means the start or end of an interval is out of range.
+var ErrInvalidInterval = errors.New("seq: invalid interval")
+
+// Interval is a region of a sequence, Start and End are 1-based and both included,
+// the same as SubSeq.
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Length returns the length of the interval.
+func (i Interval) Length() int {
+	return i.End - i.Start + 1
+}
+
+func (i Interval) String() string {
+	return fmt.Sprintf("%d-%d", i.Start, i.End)
+}
+
+// MergeIntervals sorts intervals and merges the overlapping or adjacent ones.
+// The original slice is not changed.
+func MergeIntervals(regions []Interval) []Interval {
+	if len(regions) == 0 {
+		return []Interval{}
+	}
+	tmp := make([]Interval, len(regions))
+	copy(tmp, regions)
+	sort.Slice(tmp, func(i, j int) bool {
+		if tmp[i].Start == tmp[j].Start {
+			return tmp[i].End < tmp[j].End
+		}
+		return tmp[i].Start < tmp[j].Start
+	})
+
+	merged := make([]Interval, 0, len(tmp))
+	pre := tmp[0]
+	for _, r := range tmp[1:] {
+		if r.Start <= pre.End+1 {
+			if r.End > pre.End {
+				pre.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, pre)
+		pre = r
+	}
+	merged = append(merged, pre)
+	return merged
+}
+
+// checkIntervals checks whether all intervals are in the range of [1, length].
+func checkIntervals(regions []Interval, length int) error {
+	for _, r := range regions {
+		if r.Start < 1 || r.End > length || r.Start > r.End {
+			return fmt.Errorf("%w: %s, sequence length: %d", ErrInvalidInterval, r, length)
+		}
+	}
+	return nil
+}
+
+// SoftMask returns a new sequence with the given regions converted to lower case.
+func (seq *Seq) SoftMask(regions []Interval) (*Seq, error) {
+	return seq.Clone2().SoftMaskInplace(regions)
+}
+
+// SoftMaskInplace converts the given regions to lower case in place.
+func (seq *Seq) SoftMaskInplace(regions []Interval) (*Seq, error) {
+	if err := checkIntervals(regions, len(seq.Seq)); err != nil {
+		return seq, err
+	}
+	var b byte
+	for _, r := range regions {
+		for i := r.Start - 1; i < r.End; i++ {
+			b = seq.Seq[i]
+			if b >= 'A' && b <= 'Z' {
+				seq.Seq[i] = b + 32
+			}
+		}
+	}
+	return seq, nil
+}
+
+// HardMask returns a new sequence with the bases in the given regions replaced by letter.
+// If letter is 0, 'X' is used for protein sequences, and 'N' for others.
+func (seq *Seq) HardMask(regions []Interval, letter byte) (*Seq, error) {
+	return seq.Clone2().HardMaskInplace(regions, letter)
+}
+
+// HardMaskInplace replaces the bases in the given regions with letter in place.
+// If letter is 0, 'X' is used for protein sequences, and 'N' for others.
+func (seq *Seq) HardMaskInplace(regions []Interval, letter byte) (*Seq, error) {
+	if err := checkIntervals(regions, len(seq.Seq)); err != nil {
+		return seq, err
+	}
+	if letter == 0 {
+		letter = seq.maskLetter()
+	}
+	for _, r := range regions {
+		for i := r.Start - 1; i < r.End; i++ {
+			seq.Seq[i] = letter
+		}
+	}
+	return seq, nil
+}
+
+// HardMaskSoftMasked returns a new sequence with all lower case bases replaced by letter.
+// If letter is 0, 'X' is used for protein sequences, and 'N' for others.
+func (seq *Seq) HardMaskSoftMasked(letter byte) *Seq {
+	s, _ := seq.HardMask(seq.SoftMaskedRegions(), letter)
+	return s
+}
+
+// Unmask returns a new sequence with all lower case letters converted to upper case.
+func (seq *Seq) Unmask() *Seq {
+	return seq.Clone2().UnmaskInplace()
+}
+
+// UnmaskInplace converts all lower case letters to upper case in place.
+func (seq *Seq) UnmaskInplace() *Seq {
+	var b byte
+	for i := 0; i < len(seq.Seq); i++ {
+		b = seq.Seq[i]
+		if b >= 'a' && b <= 'z' {
+			seq.Seq[i] = b - 32
+		}
+	}
+	return seq
+}
+
+func (seq *Seq) maskLetter() byte {
+	if seq.Alphabet == Protein {
+		return 'X'
+	}
+	return 'N'
+}
+
+// SoftMaskedRegions returns all runs of lower case letters.
+func (seq *Seq) SoftMaskedRegions() []Interval {
+	regions := make([]Interval, 0, 8)
+	var b byte
+	start := -1
+	for i := 0; i < len(seq.Seq); i++ {
+		b = seq.Seq[i]
+		if b >= 'a' && b <= 'z' {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			regions = append(regions, Interval{start + 1, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		regions = append(regions, Interval{start + 1, len(seq.Seq)})
+	}
+	return regions
+}
+
+// ----------------------------------------------------------------------------
+
+// DefaultDustWindow is the default window size of SDUST.
+var DefaultDustWindow = 64
+
+// DefaultDustThreshold is the default score threshold of SDUST.
+var DefaultDustThreshold = 20
+
+// ErrInvalidDustParameter means window < 4 or threshold < 1.
+var ErrInvalidDustParameter = errors.New("seq: invalid SDUST parameters, window should be >= 4 and threshold >= 1")
+
+const dustWordLen = 3
+const dustWordTotal = 1 << (dustWordLen << 1)
+const dustWordMask = dustWordTotal - 1
+
+// dustBase2bit maps A/C/G/T/U (case insensitive) to 0-3, others to 4.
+var dustBase2bit = [256]byte{}
+
+func init() {
+	for i := range dustBase2bit {
+		dustBase2bit[i] = 4
+	}
+	for _, b := range []byte("aA") {
+		dustBase2bit[b] = 0
+	}
+	for _, b := range []byte("cC") {
+		dustBase2bit[b] = 1
+	}
+	for _, b := range []byte("gG") {
+		dustBase2bit[b] = 2
+	}
+	for _, b := range []byte("tTuU") {
+		dustBase2bit[b] = 3
+	}
+}
+
+type perfectInterval struct {
+	start, finish int
+	r, l          int
+}
+
+// dustQueue is a fixed-capacity FIFO of triplets.
+type dustQueue struct {
+	data  []int
+	front int
+	count int
+}
+
+func (q *dustQueue) size() int { return q.count }
+
+func (q *dustQueue) at(i int) int { return q.data[(q.front+i)%len(q.data)] }
+
+func (q *dustQueue) push(v int) {
+	q.data[(q.front+q.count)%len(q.data)] = v
+	q.count++
+}
+
+func (q *dustQueue) shift() int {
+	v := q.data[q.front]
+	q.front = (q.front + 1) % len(q.data)
+	q.count--
+	return v
+}
+
+type sdust struct {
+	T, W   int
+	w      dustQueue
+	P      []perfectInterval
+	res    []Interval // 0-based, half open, converted at the end
+	L      int
+	rw, rv int
+	cw, cv [dustWordTotal]int
+}
+
+/*
+Dust finds low-complexity regions of a nucleotide sequence with the
+symmetric DUST algorithm (SDUST), and returns the 1-based intervals.
+Window and threshold are the window size and score threshold, 0 for
+default values (DefaultDustWindow and DefaultDustThreshold).
+Bases other than A/C/G/T/U break the sequence into independent pieces.
+
+Reference:
+
+ 1. Morgulis A, et al. A fast and symmetric DUST implementation to mask
+    low-complexity DNA sequences. J Comput Biol. 2006.
+ 2. https://github.com/lh3/sdust
+*/
+func (seq *Seq) Dust(window int, threshold int) ([]Interval, error) {
+	if window == 0 {
+		window = DefaultDustWindow
+	}
+	if threshold == 0 {
+		threshold = DefaultDustThreshold
+	}
+	if window < dustWordLen+1 || threshold < 1 {
+		return nil, ErrInvalidDustParameter
+	}
+
+	d := &sdust{T: threshold, W: window}
+	d.w.data = make([]int, window)
+	d.P = make([]perfectInterval, 0, 8)
+	d.res = make([]Interval, 0, 8)
+
+	s := seq.Seq
+	n := len(s)
+	var i, l, start int
+	var b byte
+	var t int
+	for i = 0; i <= n; i++ {
+		if i < n {
+			b = dustBase2bit[s[i]]
+		} else {
+			b = 4
+		}
+		if b < 4 {
+			l++
+			t = (t<<2 | int(b)) & dustWordMask
+			if l >= dustWordLen {
+				start = l - window
+				if start < 0 {
+					start = 0
+				}
+				start += i + 1 - l
+				d.saveMaskedRegions(start)
+				d.shiftWindow(t)
+				if d.rw*10 > d.L*d.T {
+					d.findPerfect(start)
+				}
+			}
+		} else {
+			start = l - window + 1
+			if start < 0 {
+				start = 0
+			}
+			start += i + 1 - l
+			for len(d.P) > 0 {
+				d.saveMaskedRegions(start)
+				start++
+			}
+			l, t = 0, 0
+			d.reset()
+		}
+	}
+
+	for j := range d.res {
+		d.res[j].Start++
+	}
+	return d.res, nil
+}
+
+// reset clears the window, for starting a new piece of sequence.
+func (d *sdust) reset() {
+	d.w.front, d.w.count = 0, 0
+	d.L, d.rw, d.rv = 0, 0, 0
+	d.cw = [dustWordTotal]int{}
+	d.cv = [dustWordTotal]int{}
+}
+
+func (d *sdust) shiftWindow(t int) {
+	var s int
+	if d.w.size() >= d.W-dustWordLen+1 {
+		s = d.w.shift()
+		d.cw[s]--
+		d.rw -= d.cw[s]
+		if d.L > d.w.size() {
+			d.L--
+			d.cv[s]--
+			d.rv -= d.cv[s]
+		}
+	}
+	d.w.push(t)
+	d.L++
+	d.rw += d.cw[t]
+	d.cw[t]++
+	d.rv += d.cv[t]
+	d.cv[t]++
+	if d.cv[t]*10 > d.T<<1 {
+		for {
+			s = d.w.at(d.w.size() - d.L)
+			d.cv[s]--
+			d.rv -= d.cv[s]
+			d.L--
+			if s == t {
+				break
+			}
+		}
+	}
+}
+
+func (d *sdust) saveMaskedRegions(start int) {
+	if len(d.P) == 0 || d.P[len(d.P)-1].start >= start {
+		return
+	}
+	p := d.P[len(d.P)-1]
+	saved := false
+	if len(d.res) > 0 {
+		last := &d.res[len(d.res)-1]
+		if p.start <= last.End { // merge if overlapping
+			if p.finish > last.End {
+				last.End = p.finish
+			}
+			saved = true
+		}
+	}
+	if !saved {
+		d.res = append(d.res, Interval{p.start, p.finish})
+	}
+	// remove perfect intervals that have fallen out of the window
+	i := len(d.P) - 1
+	for ; i >= 0 && d.P[i].start < start; i-- {
+	}
+	d.P = d.P[:i+1]
+}
+
+func (d *sdust) findPerfect(start int) {
+	c := d.cv
+	r := d.rv
+	var maxR, maxL int
+	var j, t, newR, newL int
+	size := d.w.size()
+	for i := size - d.L - 1; i >= 0; i-- {
+		t = d.w.at(i)
+		r += c[t]
+		c[t]++
+		newR, newL = r, size-i-1
+		if newR*10 > d.T*newL {
+			for j = 0; j < len(d.P) && d.P[j].start >= i+start; j++ {
+				p := &d.P[j]
+				if maxR == 0 || p.r*maxL > maxR*p.l {
+					maxR, maxL = p.r, p.l
+				}
+			}
+			if maxR == 0 || newR*maxL >= maxR*newL {
+				maxR, maxL = newR, newL
+				d.P = append(d.P, perfectInterval{})
+				copy(d.P[j+1:], d.P[j:])
+				d.P[j] = perfectInterval{
+					start:  i + start,
+					finish: size + (dustWordLen - 1) + start,
+					r:      newR,
+					l:      newL,
+				}
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// DefaultSEGWindow is the default window size of SEG.
+var DefaultSEGWindow = 12
+
+// DefaultSEGLowCut is the default trigger complexity (K1) of SEG.
+var DefaultSEGLowCut = 2.2
+
+// DefaultSEGHighCut is the default extension complexity (K2) of SEG.
+var DefaultSEGHighCut = 2.5
+
+// ErrInvalidSEGParameter means invalid parameters for SEG.
+var ErrInvalidSEGParameter = errors.New("seq: invalid SEG parameters, window should be >= 1 and locut <= hicut")
+
+/*
+SEG finds low-complexity regions of a protein sequence with the algorithm
+of SEG, and returns the 1-based intervals.
+
+Windows with Shannon entropy (in bits) <= locut trigger a low-complexity
+segment, which is then extended to both sides with contiguous windows with
+entropy <= hicut. Overlapping segments are merged.
+Zero values of window, locut and hicut are replaced with the default ones
+(DefaultSEGWindow, DefaultSEGLowCut, DefaultSEGHighCut).
+Letters are case insensitive, and 'X' and '*' are skipped.
+
+Note that, the final optimization step of the original SEG,
+i.e., trimming segments to the sub-segment of minimum probability, is not performed.
+
+Reference:
+
+ 1. Wootton JC, Federhen S. Statistics of local complexity in amino acid
+    sequences and sequence databases. Computers & Chemistry. 1993.
+*/
+func (seq *Seq) SEG(window int, locut float64, hicut float64) ([]Interval, error) {
+	if window == 0 {
+		window = DefaultSEGWindow
+	}
+	if locut == 0 {
+		locut = DefaultSEGLowCut
+	}
+	if hicut == 0 {
+		hicut = DefaultSEGHighCut
+	}
+	if window < 1 || locut > hicut {
+		return nil, ErrInvalidSEGParameter
+	}
+
+	regions := make([]Interval, 0, 8)
+	n := len(seq.Seq)
+	if n < window {
+		return regions, nil
+	}
+
+	// entropy of all windows, NaN for windows containing skipped letters
+	nw := n - window + 1
+	entropies := make([]float64, nw)
+	var counts [256]int
+	var nSkip int
+	var b byte
+	for i := 0; i < n; i++ {
+		b = segLetter(seq.Seq[i])
+		if b == 0 {
+			nSkip++
+		} else {
+			counts[b]++
+		}
+		if i >= window {
+			b = segLetter(seq.Seq[i-window])
+			if b == 0 {
+				nSkip--
+			} else {
+				counts[b]--
+			}
+		}
+		if i >= window-1 {
+			if nSkip > 0 {
+				entropies[i-window+1] = math.NaN()
+			} else {
+				entropies[i-window+1] = segEntropy(&counts, window)
+			}
+		}
+	}
+
+	var j, left, right int
+	for i := 0; i < nw; i++ {
+		if !(entropies[i] <= locut) { // NaN is also excluded
+			continue
+		}
+		left = i
+		for j = i - 1; j >= 0 && entropies[j] <= hicut; j-- {
+			left = j
+		}
+		right = i
+		for j = i + 1; j < nw && entropies[j] <= hicut; j++ {
+			right = j
+		}
+		regions = append(regions, Interval{left + 1, right + window})
+		i = right
+	}
+
+	return MergeIntervals(regions), nil
+}
+
+func segLetter(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		b -= 32
+	}
+	if b < 'A' || b > 'Z' || b == 'X' {
+		return 0
+	}
+	return b
+}
+
+func segEntropy(counts *[256]int, window int) float64 {
+	var h, p float64
+	w := float64(window)
+	for b := 'A'; b <= 'Z'; b++ {
+		if counts[b] == 0 {
+			continue
+		}
+		p = float64(counts[b]) / w
+		h -= p * math.Log2(p)
+	}
+	return h
+}
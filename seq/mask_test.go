@@ -0,0 +1,113 @@
+package seq
+
+import (
+	"testing"
+)
+
+func TestSoftAndHardMask(t *testing.T) {
+	s, _ := NewSeqWithoutValidation(DNA, []byte("ACGTACGTACGT"))
+
+	s2, err := s.SoftMask([]Interval{{2, 3}, {10, 12}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(s2.Seq) != "AcgTACGTAcgt" {
+		t.Errorf("soft-mask error: %s", s2.Seq)
+	}
+	if string(s.Seq) != "ACGTACGTACGT" {
+		t.Errorf("original sequence should not be changed: %s", s.Seq)
+	}
+
+	regions := s2.SoftMaskedRegions()
+	if len(regions) != 2 || regions[0] != (Interval{2, 3}) || regions[1] != (Interval{10, 12}) {
+		t.Errorf("soft-masked regions error: %v", regions)
+	}
+
+	s3 := s2.HardMaskSoftMasked(0)
+	if string(s3.Seq) != "ANNTACGTANNN" {
+		t.Errorf("hard-mask error: %s", s3.Seq)
+	}
+
+	if string(s2.Unmask().Seq) != "ACGTACGTACGT" {
+		t.Errorf("unmask error")
+	}
+
+	p, _ := NewSeqWithoutValidation(Protein, []byte("MKVLA"))
+	p2, _ := p.HardMask([]Interval{{1, 2}}, 0)
+	if string(p2.Seq) != "XXVLA" {
+		t.Errorf("hard-mask error: %s", p2.Seq)
+	}
+
+	_, err = s.SoftMask([]Interval{{0, 3}})
+	if err == nil {
+		t.Errorf("invalid interval should be reported")
+	}
+	_, err = s.HardMask([]Interval{{3, 13}}, 'N')
+	if err == nil {
+		t.Errorf("invalid interval should be reported")
+	}
+}
+
+func TestMergeIntervals(t *testing.T) {
+	m := MergeIntervals([]Interval{{10, 20}, {1, 3}, {4, 5}, {15, 25}, {30, 30}})
+	if len(m) != 3 || m[0] != (Interval{1, 5}) || m[1] != (Interval{10, 25}) || m[2] != (Interval{30, 30}) {
+		t.Errorf("merge intervals error: %v", m)
+	}
+}
+
+func TestDust(t *testing.T) {
+	left := "GATCGTAGCTAGCTGACTGATCGATCGTAGCATGCTAGCTAGCATCGAT"
+	low := "CACACACACACACACACACACACACACACACACACACACA"
+	right := "GTCAGTCGATCGATGCTAGCTAGCATCGATGCTAGTCGATGCATGCTA"
+	s, _ := NewSeqWithoutValidation(DNA, []byte(left+low+right))
+
+	regions, err := s.Dust(0, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(regions) != 1 {
+		t.Errorf("SDUST error: %v", regions)
+		return
+	}
+	r := regions[0]
+	if r.Start > len(left)+1 || r.End < len(left)+len(low) || r.Length() > len(low)+6 {
+		t.Errorf("SDUST error: %v", regions)
+	}
+
+	s, _ = NewSeqWithoutValidation(DNA, []byte(left+right))
+	regions, _ = s.Dust(0, 0)
+	if len(regions) != 0 {
+		t.Errorf("SDUST error: %v", regions)
+	}
+
+	// N breaks the sequence
+	s, _ = NewSeqWithoutValidation(DNA, []byte("AAAAAAAAAAAANNNNNAAAAAAAAAAAA"))
+	regions, _ = s.Dust(0, 0)
+	if len(regions) != 2 || regions[0] != (Interval{1, 12}) || regions[1] != (Interval{18, 29}) {
+		t.Errorf("SDUST error: %v", regions)
+	}
+}
+
+func TestSEG(t *testing.T) {
+	left := "MGLNRFMRAMMVVFITANCITINPDIIFAATDSEDSSLNTDEWEEEKTEEQPSEV"
+	low := "QQQQQQQQQQQQQQQQQQQQ"
+	right := "NTGPRYETAREVSSRDIKELEKSNKVRNTNKADLIAMLKEKAEKGP"
+	s, _ := NewSeqWithoutValidation(Protein, []byte(left+low+right))
+
+	regions, err := s.SEG(0, 0, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var found bool
+	for _, r := range regions {
+		if r.Start <= len(left)+1 && r.End >= len(left)+len(low) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("SEG error: %v", regions)
+	}
+}
@@ -0,0 +1,183 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors of merging paired-end reads, which tell why the pairs were not merged.
+var (
+	// ErrMergeShortReads means a read is shorter than the minimum overlap.
+	ErrMergeShortReads = errors.New("seq: reads too short to merge")
+	// ErrMergeNoOverlap means no overlap found with the minimum overlap
+	// and the maximum mismatch rate.
+	ErrMergeNoOverlap = errors.New("seq: no overlap found")
+	// ErrMergeDovetail means the insert is shorter than the reads,
+	// i.e., the reads extend past each other, which is not allowed.
+	ErrMergeDovetail = errors.New("seq: dovetailed reads")
+)
+
+// MergeOptions contains the options of merging paired-end reads.
+type MergeOptions struct {
+	Encoding QualityEncoding // quality encoding, Unknown for Sanger (Phred+33)
+
+	MinOverlap      int     // minimum overlap length
+	MaxMismatchRate float64 // maximum mismatch rate in the overlap
+
+	// AllowDovetail allows inserts shorter than reads, where the overhanging
+	// bases (adapters) are removed.
+	AllowDovetail bool
+
+	MaxQual int // maximum quality of merged bases, 0 for no limit
+}
+
+// DefaultMergeOptions is the default options of merging paired-end reads.
+var DefaultMergeOptions = MergeOptions{
+	MinOverlap:      16,
+	MaxMismatchRate: 0.1,
+	MaxQual:         41,
+}
+
+// MergePair merges a pair of overlapping reads with qualities into one read,
+// and returns the merged read and the overlap. Nil opt is for DefaultMergeOptions.
+// If the pair can't be merged, the error tells why, e.g., ErrMergeNoOverlap.
+//
+// In the overlap, the base with the higher quality is chosen, and the
+// quality is the posterior probability of the base being wrong given both
+// reads (Edgar & Flyvbjerg, 2015), i.e., for the same bases with error
+// probabilities e1 and e2 (see QUAL_MAP):
+//
+//	(e1*e2/3) / ((1-e1)*(1-e2) + e1*e2/3)
+//
+// and for different bases where the first one is chosen:
+//
+//	1 - (1-e1)*e2/3 / ((1-e1)*e2/3 + e1*(1-e2)/3 + 2*e1*e2/9)
+func MergePair(read1, read2 *Seq, opt *MergeOptions) (*Seq, *PairedOverlap, error) {
+	if opt == nil {
+		opt = &DefaultMergeOptions
+	}
+	for _, r := range []*Seq{read1, read2} {
+		if len(r.Qual) == 0 {
+			return nil, nil, ErrNoQuality
+		}
+		if len(r.Qual) != len(r.Seq) {
+			return nil, nil, fmt.Errorf("seq: unmatched length of sequence (%d) and quality (%d)", len(r.Seq), len(r.Qual))
+		}
+	}
+	n1, n2 := len(read1.Seq), len(read2.Seq)
+	if n1 < opt.MinOverlap || n2 < opt.MinOverlap {
+		return nil, nil, ErrMergeShortReads
+	}
+
+	overlap := FindPairedOverlap(read1.Seq, read2.Seq, opt.MinOverlap, opt.MaxMismatchRate)
+	if overlap == nil {
+		return nil, nil, ErrMergeNoOverlap
+	}
+	o, insert := overlap.Offset, overlap.InsertSize
+	if !opt.AllowDovetail && (o < 0 || insert < n1) {
+		return nil, overlap, ErrMergeDovetail
+	}
+
+	qv1, err := opt.Encoding.PhredValues(read1.Qual)
+	if err != nil {
+		return nil, overlap, err
+	}
+	qv2, err := opt.Encoding.PhredValues(read2.Qual)
+	if err != nil {
+		return nil, overlap, err
+	}
+
+	// positions of the merged read are the ones in read 1,
+	// and the reverse complement of read 2 starts at o.
+	s := make([]byte, insert)
+	qv := make([]int, insert)
+	var b1, b2 byte
+	var q1, q2, j int
+	var has1, has2 bool
+	for i := 0; i < insert; i++ {
+		j = i - o
+		has1, has2 = i < n1, j >= 0
+		if has1 {
+			b1, q1 = read1.Seq[i], qv1[i]
+		}
+		if has2 {
+			b2, q2 = complementNucleotide(read2.Seq[n2-1-j]), qv2[n2-1-j]
+		}
+		switch {
+		case has1 && has2:
+			s[i], qv[i] = mergeBase(b1, q1, b2, q2, opt.MaxQual)
+		case has1:
+			s[i], qv[i] = b1, q1
+		default:
+			s[i], qv[i] = b2, q2
+		}
+	}
+
+	qual := make([]byte, insert)
+	for i, q := range qv {
+		qual[i] = byte(q + 33)
+	}
+	encoding := opt.Encoding
+	if encoding == Unknown {
+		encoding = Sanger
+	}
+	if qual, err = QualityConvert(Sanger, encoding, qual, false); err != nil {
+		return nil, overlap, err
+	}
+
+	merged, err := NewSeqWithQualWithoutValidation(read1.Alphabet, s, qual)
+	if err != nil {
+		return nil, overlap, err
+	}
+	return merged, overlap, nil
+}
+
+// maxPhred is the maximum Phred quality could be encoded in ASCII.
+const maxPhred = 93
+
+// mergeBase computes the consensus base and its quality of two bases.
+func mergeBase(b1 byte, q1 int, b2 byte, q2 int, maxQual int) (byte, int) {
+	n1, n2 := b1 == 'N' || b1 == 'n', b2 == 'N' || b2 == 'n'
+	switch {
+	case n1 && n2:
+		return b1, 0
+	case n1:
+		return b2, q2
+	case n2:
+		return b1, q1
+	}
+
+	if q1 > 255 {
+		q1 = 255
+	}
+	if q2 > 255 {
+		q2 = 255
+	}
+	e1, e2 := QUAL_MAP[q1], QUAL_MAP[q2]
+	var b byte
+	var p float64
+	if toUpper(b1) == toUpper(b2) {
+		b = b1
+		p = e1 * e2 / 3 / ((1-e1)*(1-e2) + e1*e2/3)
+	} else {
+		if q2 > q1 {
+			b1, b2, e1, e2 = b2, b1, e2, e1
+		}
+		b = b1
+		c := (1 - e1) * e2 / 3
+		p = 1 - c/(c+e1*(1-e2)/3+2*e1*e2/9)
+	}
+
+	q := maxPhred
+	if p > 0 {
+		q = int(math.Round(-10 * math.Log10(p)))
+	}
+	if q > maxPhred {
+		q = maxPhred
+	}
+	if maxQual > 0 && q > maxQual {
+		q = maxQual
+	}
+	return b, q
+}
@@ -0,0 +1,74 @@
+package seq
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMergePair(t *testing.T) {
+	frag, _ := NewSeq(DNA, []byte("ACGTTGCAAGGCTTAACCTGGATCCATGCAGTTGACCAGTGGCATTACGA"))
+
+	r1, _ := NewSeqWithQual(DNA, []byte(string(frag.Seq[:30])), bytes.Repeat([]byte("?"), 30))
+	r2 := frag.SubSeq(21, 50).RevCom()
+	r2.Qual = bytes.Repeat([]byte("5"), 30)
+
+	opt := DefaultMergeOptions
+	opt.MinOverlap = 8
+	merged, overlap, err := MergePair(r1, r2, &opt)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(merged.Seq) != string(frag.Seq) {
+		t.Errorf("merged sequence error: %s", merged.Seq)
+	}
+	if overlap.Length != 10 || overlap.Offset != 20 {
+		t.Errorf("overlap error: %v", overlap)
+	}
+	// Q30 + Q20 of the same base -> Q41 (capped), Q30 only for read 1, Q20 only for read 2
+	if merged.Qual[0] != '?' || merged.Qual[25] != 'J' || merged.Qual[49] != '5' {
+		t.Errorf("merged quality error: %s", merged.Qual)
+	}
+
+	// a mismatch in the overlap: the base of the higher quality wins
+	r1.Seq[25] = 'T' // A -> T, Q30 vs Q20
+	merged, _, err = MergePair(r1, r2, &opt)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if merged.Seq[25] != 'T' || merged.Qual[25] != '+' {
+		t.Errorf("merging mismatched bases error: %c %c", merged.Seq[25], merged.Qual[25])
+	}
+	r1.Seq[25] = 'A'
+
+	// no overlap
+	r3 := frag.SubSeq(35, 50).RevCom()
+	r3.Qual = bytes.Repeat([]byte("5"), len(r3.Seq))
+	opt.MinOverlap = 10
+	if _, _, err = MergePair(r1, r3, &opt); !errors.Is(err, ErrMergeNoOverlap) {
+		t.Errorf("ErrMergeNoOverlap should be reported: %v", err)
+	}
+
+	// dovetail: the insert (frag[:20]) is shorter than the reads
+	d1, _ := NewSeqWithQual(DNA, []byte(string(frag.Seq[:20])+"AGATCGGAAG"), bytes.Repeat([]byte("?"), 30))
+	d2, _ := NewSeqWithQual(DNA, []byte(string(frag.SubSeq(1, 20).RevCom().Seq)+"AGATCGGTTC"), bytes.Repeat([]byte("?"), 30))
+	if _, _, err = MergePair(d1, d2, &opt); !errors.Is(err, ErrMergeDovetail) {
+		t.Errorf("ErrMergeDovetail should be reported: %v", err)
+	}
+	opt.AllowDovetail = true
+	merged, _, err = MergePair(d1, d2, &opt)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(merged.Seq) != string(frag.Seq[:20]) {
+		t.Errorf("merging dovetailed reads error: %s", merged.Seq)
+	}
+
+	r1.Qual = nil
+	if _, _, err = MergePair(r1, r2, &opt); !errors.Is(err, ErrNoQuality) {
+		t.Errorf("ErrNoQuality should be reported: %v", err)
+	}
+}
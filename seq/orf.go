@@ -0,0 +1,381 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Frames are all the six translation frames.
+var Frames = []int{1, 2, 3, -1, -2, -3}
+
+// TranslateSixFrames translates the RNA/DNA in all six frames, in the order of Frames.
+// Options are the same as those of Translate.
+func (seq *Seq) TranslateSixFrames(transl_table int, trim bool, clean bool, allowUnknownCodon bool, markInitCodonAsM bool) ([]*Seq, error) {
+	proteins := make([]*Seq, len(Frames))
+	var err error
+	for i, frame := range Frames {
+		proteins[i], err = seq.Translate(transl_table, frame, trim, clean, allowUnknownCodon, markInitCodonAsM)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proteins, nil
+}
+
+// ORFMode decides which ORFs sharing the same stop codon are reported.
+type ORFMode int
+
+const (
+	// ORFLongest only reports the longest ORF of each stop codon,
+	// i.e., the one starting at the first start codon after the previous stop codon.
+	ORFLongest ORFMode = iota
+	// ORFNested reports ORFs starting from all start codons, including the nested ones.
+	ORFNested
+)
+
+// ORFOptions contains the options for FindORFs.
+type ORFOptions struct {
+	CodonTable int     // transl_table, 0 for the standard code (1)
+	Frames     []int   // frames to search, nil for all six frames
+	Mode       ORFMode // ORFLongest or ORFNested
+	MinLen     int     // minimum length of ORFs in nucleotides, including the stop codon
+
+	// AltStartCodons means using all initiation codons in the codon table,
+	// otherwise only ATG/AUG is used.
+	AltStartCodons bool
+
+	// Circular means the sequence is circular, and ORFs spanning the end
+	// of the sequence are reported. ORFs longer than the sequence are omitted.
+	Circular bool
+
+	// AllowPartial means reporting partial ORFs at the edges of linear sequences,
+	// i.e., ORFs without a start codon at the 5' end, and ORFs without a stop
+	// codon at the 3' end.
+	AllowPartial bool
+}
+
+// ORF represents an open reading frame.
+type ORF struct {
+	// Frame is the translation frame: 1, 2, 3, -1, -2, -3.
+	Frame int
+
+	// Start and End are 1-based locations on the positive strand, and both included.
+	// For ORFs spanning the end of a circular sequence, Start > End.
+	Start, End int
+
+	// Length is the length in nucleotides, including the stop codon.
+	Length int
+
+	PartialStart bool // no start codon
+	PartialEnd   bool // no stop codon
+
+	// Protein is the translated protein, stop codon excluded.
+	// Start codon is translated to 'M' for complete ORFs.
+	Protein *Seq
+}
+
+func (orf *ORF) String() string {
+	return fmt.Sprintf("frame:%d, location:%d-%d, len:%d, partial:%v/%v, protein:%s",
+		orf.Frame, orf.Start, orf.End, orf.Length, orf.PartialStart, orf.PartialEnd, orf.Protein.Seq)
+}
+
+// ErrInvalidFrame means the frame is not one of 1, 2, 3, -1, -2, -3.
+var ErrInvalidFrame = errors.New("seq: invalid frame, available: 1, 2, 3, -1, -2, -3")
+
+func (seq *Seq) isNucleotide() bool {
+	switch seq.Alphabet.String() {
+	case "DNA", "DNAredundant", "RNA", "RNAredundant":
+		return true
+	}
+	return false
+}
+
+// FindORFs finds open reading frames in all or given frames of a DNA/RNA sequence.
+// A nil opt means the default options, i.e., the standard code, all six frames,
+// longest ORFs, ATG as the only start codon, and linear sequence without partial ORFs.
+//
+// ORFs are sorted by Start and then Frame.
+func (seq *Seq) FindORFs(opt *ORFOptions) ([]*ORF, error) {
+	if !seq.isNucleotide() {
+		return nil, fmt.Errorf("seq: only DNA/RNA sequence can call method FindORFs, the alphabet is %s", seq.Alphabet.String())
+	}
+	if opt == nil {
+		opt = &ORFOptions{}
+	}
+	id := opt.CodonTable
+	if id == 0 {
+		id = 1
+	}
+	codonTable, ok := CodonTables[id]
+	if !ok {
+		return nil, fmt.Errorf("seq: invalid codon table: %d", id)
+	}
+	frames := opt.Frames
+	if len(frames) == 0 {
+		frames = Frames
+	}
+	var positive, negative bool
+	for _, f := range frames {
+		switch f {
+		case 1, 2, 3:
+			positive = true
+		case -1, -2, -3:
+			negative = true
+		default:
+			return nil, fmt.Errorf("%w: %d", ErrInvalidFrame, f)
+		}
+	}
+
+	finder := &orfFinder{table: codonTable, opt: opt, frames: frames}
+
+	orfs := make([]*ORF, 0, 8)
+	if len(seq.Seq) < 3 {
+		return orfs, nil
+	}
+	if positive {
+		orfs = finder.find(seq.Seq, false, orfs)
+	}
+	if negative {
+		orfs = finder.find(seq.RevCom().Seq, true, orfs)
+	}
+
+	sort.Slice(orfs, func(i, j int) bool {
+		if orfs[i].Start == orfs[j].Start {
+			return orfs[i].Frame > orfs[j].Frame
+		}
+		return orfs[i].Start < orfs[j].Start
+	})
+	return orfs, nil
+}
+
+type orfFinder struct {
+	table  *CodonTable
+	opt    *ORFOptions
+	frames []int
+
+	s      []byte // sequence of the strand
+	n      int
+	rc     bool
+	codon  []byte
+	pos    []int // start positions of codons
+	stop   []bool
+	start  []bool
+	wanted [3]bool // wanted frames of this strand
+}
+
+func (f *orfFinder) find(s []byte, rc bool, orfs []*ORF) []*ORF {
+	f.s = s
+	f.n = len(s)
+	f.rc = rc
+	f.codon = make([]byte, 3)
+	f.wanted = [3]bool{}
+	for _, frame := range f.frames {
+		if rc && frame < 0 {
+			f.wanted[-frame-1] = true
+		} else if !rc && frame > 0 {
+			f.wanted[frame-1] = true
+		}
+	}
+
+	n := f.n
+	if !f.opt.Circular {
+		for i := 0; i < 3; i++ {
+			if !f.wanted[i] {
+				continue
+			}
+			f.pos = f.pos[:0]
+			for p := i; p+3 <= n; p += 3 {
+				f.pos = append(f.pos, p)
+			}
+			orfs = f.findLinear(orfs)
+		}
+		return orfs
+	}
+
+	if n%3 == 0 { // three independent cycles
+		for i := 0; i < 3; i++ {
+			if !f.wanted[i] {
+				continue
+			}
+			f.pos = f.pos[:0]
+			for p := i; p < n; p += 3 {
+				f.pos = append(f.pos, p)
+			}
+			orfs = f.findCircular(orfs)
+		}
+		return orfs
+	}
+
+	// one cycle covering all three frames
+	if !f.wanted[0] && !f.wanted[1] && !f.wanted[2] {
+		return orfs
+	}
+	f.pos = f.pos[:0]
+	for k := 0; k < n; k++ {
+		f.pos = append(f.pos, (3*k)%n)
+	}
+	return f.findCircular(orfs)
+}
+
+// codonAt returns the codon starting at p, the sequence is treated as circular.
+func (f *orfFinder) codonAt(p int) []byte {
+	f.codon[0] = f.s[p%f.n]
+	f.codon[1] = f.s[(p+1)%f.n]
+	f.codon[2] = f.s[(p+2)%f.n]
+	return f.codon
+}
+
+func (f *orfFinder) classify() {
+	m := len(f.pos)
+	f.stop = make([]bool, m)
+	f.start = make([]bool, m)
+	var aa byte
+	var codon []byte
+	for k, p := range f.pos {
+		codon = f.codonAt(p)
+		aa, _ = f.table.Get(codon, true)
+		f.stop[k] = aa == '*'
+		f.start[k] = f.isStartCodon(codon)
+	}
+}
+
+func (f *orfFinder) isStartCodon(codon []byte) bool {
+	var c [3]byte
+	for i, b := range codon {
+		switch b {
+		case 'u', 'U', 't':
+			b = 'T'
+		case 'a':
+			b = 'A'
+		case 'c':
+			b = 'C'
+		case 'g':
+			b = 'G'
+		}
+		c[i] = b
+	}
+	if !f.opt.AltStartCodons {
+		return c == [3]byte{'A', 'T', 'G'}
+	}
+	_, ok := f.table.InitCodons[string(c[:])]
+	return ok
+}
+
+func (f *orfFinder) findLinear(orfs []*ORF) []*ORF {
+	f.classify()
+	m := len(f.pos)
+	nested := f.opt.Mode == ORFNested
+	partial := f.opt.AllowPartial
+
+	var segStart, nStop int
+	var atEnd bool
+	for k := 0; k <= m; k++ {
+		atEnd = k == m
+		if !atEnd && !f.stop[k] {
+			continue
+		}
+		if atEnd && !partial {
+			break
+		}
+		nStop = 1
+		if atEnd {
+			nStop = 0
+		}
+		// codons in [segStart, k) are free of stop codons
+		for j := segStart; j < k; j++ {
+			if f.start[j] {
+				orfs = f.addORF(orfs, f.pos[j], k-j+nStop, !atEnd, false)
+			} else if j == 0 && partial {
+				orfs = f.addORF(orfs, f.pos[j], k-j+nStop, !atEnd, true)
+			} else {
+				continue
+			}
+			if !nested {
+				break
+			}
+		}
+		segStart = k + 1
+	}
+	return orfs
+}
+
+func (f *orfFinder) findCircular(orfs []*ORF) []*ORF {
+	f.classify()
+	m := len(f.pos)
+	nested := f.opt.Mode == ORFNested
+
+	stops := make([]int, 0, 8)
+	for k := 0; k < m; k++ {
+		if f.stop[k] {
+			stops = append(stops, k)
+		}
+	}
+	if len(stops) == 0 {
+		return orfs
+	}
+
+	var kp, d, nCodons int
+	for i, k := range stops {
+		if i == 0 {
+			kp = stops[len(stops)-1]
+		} else {
+			kp = stops[i-1]
+		}
+		d = (k - kp - 1 + m) % m // number of codons between the two stop codons
+		if len(stops) == 1 {
+			d = m - 1
+		}
+		for o := 0; o < d; o++ {
+			j := (kp + 1 + o) % m
+			if !f.start[j] {
+				continue
+			}
+			nCodons = d - o + 1
+			if nCodons*3 > f.n {
+				continue
+			}
+			if f.wanted[f.pos[j]%3] { // the cycle may cover unwanted frames
+				orfs = f.addORF(orfs, f.pos[j], nCodons, true, false)
+			}
+			if !nested {
+				break
+			}
+		}
+	}
+	return orfs
+}
+
+// addORF adds an ORF starting at p of the strand, with nCodons codons.
+func (f *orfFinder) addORF(orfs []*ORF, p int, nCodons int, hasStop bool, partialStart bool) []*ORF {
+	L := nCodons * 3
+	if L < f.opt.MinLen || L == 0 {
+		return orfs
+	}
+
+	nt := make([]byte, L)
+	for i := 0; i < L; i++ {
+		nt[i] = f.s[(p+i)%f.n]
+	}
+	aa, err := f.table.Translate(nt, 1, false, false, true, !partialStart)
+	if err != nil {
+		return orfs
+	}
+	if hasStop && len(aa) > 0 {
+		aa = aa[:len(aa)-1]
+	}
+	protein, _ := NewSeqWithoutValidation(Protein, aa)
+
+	orf := &ORF{Length: L, PartialStart: partialStart, PartialEnd: !hasStop, Protein: protein}
+	n := f.n
+	if f.rc {
+		orf.Frame = -(p%3 + 1)
+		orf.Start = ((n-p-L)%n+n)%n + 1
+		orf.End = n - p
+	} else {
+		orf.Frame = p%3 + 1
+		orf.Start = p + 1
+		orf.End = (p+L-1)%n + 1
+	}
+	return append(orfs, orf)
+}
@@ -0,0 +1,135 @@
+package seq
+
+import (
+	"testing"
+)
+
+var orfTestNt = "atggaggaacaagcatggcgagaagtcctcgaacgtttagctcgaattgaaacaaagttagataactatgaaacagttcgagataaagcagaacgagcgctcctaatagctcaatcaaatgcgaaacttatagaaaaaatggaagctaataataagtgggcttggggctttatgcttactcttgccgtaactgttattggttatttattcactaaaattagattctga"
+var orfTestAa = "MEEQAWREVLERLARIETKLDNYETVRDKAERALLIAQSNAKLIEKMEANNKWAWGFMLTLAVTVIGYLFTKIRF"
+
+func TestFindORFs(t *testing.T) {
+	left, right := "ccgtaa", "tagcccgg"
+	s, _ := NewSeq(DNA, []byte(left+orfTestNt+right))
+
+	opt := &ORFOptions{CodonTable: 11, MinLen: 150}
+	orfs, err := s.FindORFs(opt)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(orfs) != 1 {
+		t.Errorf("ORF number error: %d", len(orfs))
+		return
+	}
+	orf := orfs[0]
+	if orf.Frame != 1 || orf.Start != len(left)+1 || orf.End != len(left)+len(orfTestNt) ||
+		orf.PartialStart || orf.PartialEnd || string(orf.Protein.Seq) != orfTestAa {
+		t.Errorf("ORF error: %s", orf)
+	}
+
+	// nested ORFs
+	orfs, _ = s.FindORFs(&ORFOptions{CodonTable: 11, Frames: []int{1}, Mode: ORFNested})
+	var nNested int
+	for _, orf = range orfs {
+		if orf.End == len(left)+len(orfTestNt) {
+			nNested++
+		}
+	}
+	if nNested != 3 { // M1, M47, M58
+		t.Errorf("nested ORF number error: %d", nNested)
+	}
+
+	// reverse complement strand
+	opt.Mode = ORFLongest
+	rc := s.RevCom()
+	orfs, _ = rc.FindORFs(opt)
+	if len(orfs) != 1 {
+		t.Errorf("ORF number error: %d", len(orfs))
+		return
+	}
+	orf = orfs[0]
+	if orf.Frame != -1 || orf.Start != len(right)+1 || orf.End != len(right)+len(orfTestNt) ||
+		string(orf.Protein.Seq) != orfTestAa {
+		t.Errorf("ORF error: %s", orf)
+	}
+
+	// circular sequence, the ORF spans the end
+	x := 100
+	s2, _ := NewSeq(DNA, []byte(orfTestNt[x:]+right+left+orfTestNt[:x]))
+	opt.Circular = true
+	orfs, _ = s2.FindORFs(opt)
+	if len(orfs) != 1 {
+		t.Errorf("ORF number error: %d", len(orfs))
+		return
+	}
+	orf = orfs[0]
+	if orf.Start != len(s2.Seq)-x+1 || orf.End != len(orfTestNt)-x ||
+		string(orf.Protein.Seq) != orfTestAa {
+		t.Errorf("circular ORF error: %s", orf)
+	}
+	opt.Circular = false
+	orfs, _ = s2.FindORFs(opt)
+	if len(orfs) != 0 {
+		t.Errorf("ORF number error: %d", len(orfs))
+	}
+
+	// partial ORFs
+	opt.AllowPartial = true
+	opt.MinLen = 90
+	opt.Frames = []int{1, 2, 3}
+	orfs, _ = s2.FindORFs(opt)
+	var nPartialStart, nPartialEnd int
+	for _, orf = range orfs {
+		if orf.PartialStart && orf.Frame == 3 && orf.Start == 3 &&
+			orf.End == len(orfTestNt)-x && !orf.PartialEnd {
+			nPartialStart++
+		}
+		if orf.PartialEnd && orf.Frame == 2 && orf.Start == len(s2.Seq)-x+1 && !orf.PartialStart {
+			nPartialEnd++
+		}
+	}
+	if nPartialStart != 1 || nPartialEnd != 1 {
+		t.Errorf("partial ORF error: %v", orfs)
+	}
+}
+
+func TestFindORFsCircularFrames(t *testing.T) {
+	// the length is not a multiple of 3, one cycle covers all three frames
+	s, _ := NewSeq(DNA, []byte("ATGAAATAGCCCCCCCCCCC"))
+	for _, c := range []struct {
+		frames []int
+		n      int
+	}{
+		{nil, 1},
+		{[]int{1}, 1},
+		{[]int{2}, 0},
+		{[]int{2, 3, -1, -2, -3}, 0},
+	} {
+		orfs, err := s.FindORFs(&ORFOptions{Frames: c.frames, Circular: true})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(orfs) != c.n {
+			t.Errorf("frames %v: ORF number error: %v", c.frames, orfs)
+			continue
+		}
+		for _, orf := range orfs {
+			if orf.Frame != 1 || orf.Start != 1 || orf.End != 9 {
+				t.Errorf("frames %v: ORF error: %s", c.frames, orf)
+			}
+		}
+	}
+}
+
+func TestTranslateSixFrames(t *testing.T) {
+	s, _ := NewSeq(DNA, []byte(orfTestNt))
+	proteins, err := s.TranslateSixFrames(11, false, false, true, false)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(proteins) != 6 || string(proteins[0].Seq) != orfTestAa+"*" {
+		t.Errorf("six-frame translation error")
+	}
+}
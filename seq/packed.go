@@ -0,0 +1,367 @@
+package seq
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/zeebo/wyhash"
+)
+
+// PackedSequence is the common interface of bit-packed nucleotide sequences,
+// i.e., PackedSeq and PackedSeq4.
+type PackedSequence interface {
+	Len() int      // sequence length
+	At(i int) byte // the upper-case letter at the 0-based position i
+	ToSeq() *Seq   // unpacking
+	Hash() uint64  // hash value of the sequence content
+	Alphabet() *Alphabet
+}
+
+// BaseRun is a run of an identical letter which could not be stored in 2 bits,
+// e.g., N, other degenerate bases and gaps.
+type BaseRun struct {
+	Start  int  // 0-based start position
+	Length int  // length of the run
+	Letter byte // upper-case letter
+}
+
+// PackedSeq is a compact DNA/RNA sequence, where A, C, G and T (U for RNA)
+// are packed into 2 bits, and other letters are stored in a side list of
+// runs. PackedSeq is case-insensitive, letters are stored in upper case.
+//
+// Letters in runs are also given 2-bit codes in the packed data, the same as
+// the ones used by k-mer iterators in package sketches, i.e., a degenerate
+// base is coded as one of the bases it represents, and others (e.g., gaps) as A.
+type PackedSeq struct {
+	alphabet *Alphabet
+	data     []byte // 4 bases per byte, the first base in the highest 2 bits
+	length   int
+	runs     []BaseRun
+}
+
+// packedBases are the bases of 2-bit codes.
+var packedBases = [2][4]byte{{'A', 'C', 'G', 'T'}, {'A', 'C', 'G', 'U'}}
+
+// twoBitCode returns the 2-bit code of an upper-case letter, and
+// whether the letter could be exactly restored from the code.
+func twoBitCode(b byte, rna bool) (byte, bool) {
+	switch b {
+	case 'A':
+		return 0, true
+	case 'C':
+		return 1, true
+	case 'G':
+		return 2, true
+	case 'T':
+		return 3, !rna
+	case 'U':
+		return 3, rna
+	case 'B', 'S', 'Y':
+		return 1, false
+	case 'K':
+		return 2, false
+	}
+	return 0, false
+}
+
+func toUpper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - 32
+	}
+	return b
+}
+
+func isRNA(a *Alphabet) bool {
+	return a == RNA || a == RNAredundant
+}
+
+// NewPackedSeq packs a DNA/RNA sequence into 2 bits per base.
+func NewPackedSeq(s *Seq) (*PackedSeq, error) {
+	if !s.isNucleotide() {
+		return nil, fmt.Errorf("seq: only DNA/RNA sequences can be packed, the alphabet is %s", s.Alphabet)
+	}
+	rna := isRNA(s.Alphabet)
+	p := &PackedSeq{
+		alphabet: s.Alphabet,
+		data:     make([]byte, (len(s.Seq)+3)>>2),
+		length:   len(s.Seq),
+	}
+	var code byte
+	var exact bool
+	var n int
+	for i, b := range s.Seq {
+		b = toUpper(b)
+		code, exact = twoBitCode(b, rna)
+		p.data[i>>2] |= code << ((3 - uint(i&3)) << 1)
+		if exact {
+			continue
+		}
+		n = len(p.runs)
+		if n > 0 && p.runs[n-1].Letter == b && p.runs[n-1].Start+p.runs[n-1].Length == i {
+			p.runs[n-1].Length++
+		} else {
+			p.runs = append(p.runs, BaseRun{Start: i, Length: 1, Letter: b})
+		}
+	}
+	return p, nil
+}
+
+// Alphabet returns the alphabet of the sequence.
+func (p *PackedSeq) Alphabet() *Alphabet { return p.alphabet }
+
+// Len returns the sequence length.
+func (p *PackedSeq) Len() int { return p.length }
+
+// Runs returns the runs of letters other than A, C, G and T (U for RNA).
+// The returned slice should not be edited.
+func (p *PackedSeq) Runs() []BaseRun { return p.runs }
+
+// Code returns the 2-bit code of the base at the 0-based position i.
+func (p *PackedSeq) Code(i int) byte {
+	return p.data[i>>2] >> ((3 - uint(i&3)) << 1) & 3
+}
+
+// At returns the upper-case letter at the 0-based position i.
+func (p *PackedSeq) At(i int) byte {
+	if i < 0 || i >= p.length {
+		panic(fmt.Sprintf("seq: index out of range [%d] with length %d", i, p.length))
+	}
+	if len(p.runs) > 0 {
+		j := sort.Search(len(p.runs), func(j int) bool { return p.runs[j].Start+p.runs[j].Length > i })
+		if j < len(p.runs) && p.runs[j].Start <= i {
+			return p.runs[j].Letter
+		}
+	}
+	if isRNA(p.alphabet) {
+		return packedBases[1][p.Code(i)]
+	}
+	return packedBases[0][p.Code(i)]
+}
+
+// SubSeq returns a sub sequence, start and end are 1-based,
+// and negative positions are supported as Seq.SubSeq.
+func (p *PackedSeq) SubSeq(start int, end int) *PackedSeq {
+	start, end, ok := SubLocation(p.length, start, end)
+	if !ok {
+		return &PackedSeq{alphabet: p.alphabet, data: []byte{}}
+	}
+	start--
+	n := end - start
+	s := &PackedSeq{
+		alphabet: p.alphabet,
+		data:     make([]byte, (n+3)>>2),
+		length:   n,
+	}
+	for i := 0; i < n; i++ {
+		s.data[i>>2] |= p.Code(start+i) << ((3 - uint(i&3)) << 1)
+	}
+	var b, e int
+	for _, r := range p.runs {
+		b, e = r.Start, r.Start+r.Length
+		if e <= start {
+			continue
+		}
+		if b >= end {
+			break
+		}
+		if b < start {
+			b = start
+		}
+		if e > end {
+			e = end
+		}
+		s.runs = append(s.runs, BaseRun{Start: b - start, Length: e - b, Letter: r.Letter})
+	}
+	return s
+}
+
+// RevCom returns the reverse complement sequence.
+func (p *PackedSeq) RevCom() *PackedSeq {
+	rna := isRNA(p.alphabet)
+	s := &PackedSeq{
+		alphabet: p.alphabet,
+		data:     make([]byte, len(p.data)),
+		length:   p.length,
+		runs:     make([]BaseRun, len(p.runs)),
+	}
+	l := p.length - 1
+	for i := 0; i <= l; i++ {
+		s.data[i>>2] |= (3 - p.Code(l-i)) << ((3 - uint(i&3)) << 1)
+	}
+
+	var c, code byte
+	var j, k int
+	for i, r := range p.runs {
+		c = toUpper(complementNucleotide(r.Letter))
+		code, _ = twoBitCode(c, rna)
+		j = len(p.runs) - 1 - i
+		s.runs[j] = BaseRun{Start: p.length - r.Start - r.Length, Length: r.Length, Letter: c}
+		for k = s.runs[j].Start; k < s.runs[j].Start+r.Length; k++ {
+			s.data[k>>2] = s.data[k>>2]&^(3<<((3-uint(k&3))<<1)) | code<<((3-uint(k&3))<<1)
+		}
+	}
+	return s
+}
+
+// Hash returns the hash value of the sequence content, i.e.,
+// equal sequences have the same hash value.
+func (p *PackedSeq) Hash() uint64 {
+	h := wyhash.Hash(p.data, uint64(p.length))
+	if len(p.runs) == 0 {
+		return h
+	}
+	buf := make([]byte, 0, len(p.runs)*17)
+	for _, r := range p.runs {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(r.Start))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(r.Length))
+		buf = append(buf, r.Letter)
+	}
+	return wyhash.Hash(buf, h)
+}
+
+// ToSeq unpacks the sequence.
+func (p *PackedSeq) ToSeq() *Seq {
+	bases := &packedBases[0]
+	if isRNA(p.alphabet) {
+		bases = &packedBases[1]
+	}
+	s := make([]byte, p.length)
+	for i := range s {
+		s[i] = bases[p.Code(i)]
+	}
+	var i int
+	for _, r := range p.runs {
+		for i = r.Start; i < r.Start+r.Length; i++ {
+			s[i] = r.Letter
+		}
+	}
+	seq, _ := NewSeqWithoutValidation(p.alphabet, s)
+	return seq
+}
+
+// ------------------------------------------------------------------------
+
+// PackedSeq4 is a compact DNA/RNA sequence, where IUPAC nucleotide codes
+// and the gap '-' are packed into 4 bits, i.e., A: 0001, C: 0010, G: 0100,
+// T/U: 1000, and degenerate bases are the bitwise OR of the bases they represent.
+// PackedSeq4 is case-insensitive, letters are stored in upper case.
+type PackedSeq4 struct {
+	alphabet *Alphabet
+	data     []byte // 2 bases per byte, the first base in the high 4 bits
+	length   int
+}
+
+// NewPackedSeq4 packs a DNA/RNA sequence into 4 bits per base.
+// ErrInvalidDNABase is returned for letters other than IUPAC codes and '-'.
+func NewPackedSeq4(s *Seq) (*PackedSeq4, error) {
+	if !s.isNucleotide() {
+		return nil, fmt.Errorf("seq: only DNA/RNA sequences can be packed, the alphabet is %s", s.Alphabet)
+	}
+	p := &PackedSeq4{
+		alphabet: s.Alphabet,
+		data:     make([]byte, (len(s.Seq)+1)>>1),
+		length:   len(s.Seq),
+	}
+	var c int
+	var err error
+	for i, b := range s.Seq {
+		c, err = base2code(b)
+		if err != nil || (c == 0 && b != '-') {
+			return nil, fmt.Errorf("%w: %c at position %d", ErrInvalidDNABase, b, i+1)
+		}
+		p.data[i>>1] |= byte(c) << ((1 - uint(i&1)) << 2)
+	}
+	return p, nil
+}
+
+// Alphabet returns the alphabet of the sequence.
+func (p *PackedSeq4) Alphabet() *Alphabet { return p.alphabet }
+
+// Len returns the sequence length.
+func (p *PackedSeq4) Len() int { return p.length }
+
+// Code returns the 4-bit code of the base at the 0-based position i.
+func (p *PackedSeq4) Code(i int) byte {
+	return p.data[i>>1] >> ((1 - uint(i&1)) << 2) & 15
+}
+
+// At returns the upper-case letter at the 0-based position i.
+func (p *PackedSeq4) At(i int) byte {
+	if i < 0 || i >= p.length {
+		panic(fmt.Sprintf("seq: index out of range [%d] with length %d", i, p.length))
+	}
+	c := p.Code(i)
+	if c == 8 && isRNA(p.alphabet) {
+		return 'U'
+	}
+	return code2base[c]
+}
+
+// SubSeq returns a sub sequence, start and end are 1-based,
+// and negative positions are supported as Seq.SubSeq.
+func (p *PackedSeq4) SubSeq(start int, end int) *PackedSeq4 {
+	start, end, ok := SubLocation(p.length, start, end)
+	if !ok {
+		return &PackedSeq4{alphabet: p.alphabet, data: []byte{}}
+	}
+	start--
+	n := end - start
+	s := &PackedSeq4{
+		alphabet: p.alphabet,
+		data:     make([]byte, (n+1)>>1),
+		length:   n,
+	}
+	if start&1 == 0 {
+		copy(s.data, p.data[start>>1:])
+		if n&1 == 1 {
+			s.data[len(s.data)-1] &= 0xf0
+		}
+		return s
+	}
+	for i := 0; i < n; i++ {
+		s.data[i>>1] |= p.Code(start+i) << ((1 - uint(i&1)) << 2)
+	}
+	return s
+}
+
+// complement4 maps 4-bit codes to the ones of their complements,
+// i.e., reversing the 4 bits.
+var complement4 = [16]byte{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}
+
+// RevCom returns the reverse complement sequence.
+func (p *PackedSeq4) RevCom() *PackedSeq4 {
+	s := &PackedSeq4{
+		alphabet: p.alphabet,
+		data:     make([]byte, len(p.data)),
+		length:   p.length,
+	}
+	l := p.length - 1
+	for i := 0; i <= l; i++ {
+		s.data[i>>1] |= complement4[p.Code(l-i)] << ((1 - uint(i&1)) << 2)
+	}
+	return s
+}
+
+// Hash returns the hash value of the sequence content, i.e.,
+// equal sequences have the same hash value.
+func (p *PackedSeq4) Hash() uint64 {
+	return wyhash.Hash(p.data, uint64(p.length))
+}
+
+// ToSeq unpacks the sequence.
+func (p *PackedSeq4) ToSeq() *Seq {
+	s := make([]byte, p.length)
+	for i := range s {
+		s[i] = code2base[p.Code(i)]
+	}
+	if isRNA(p.alphabet) {
+		for i, b := range s {
+			if b == 'T' {
+				s[i] = 'U'
+			}
+		}
+	}
+	seq, _ := NewSeqWithoutValidation(p.alphabet, s)
+	return seq
+}
@@ -0,0 +1,131 @@
+package seq
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPackedSeq(t *testing.T) {
+	_s := []byte("NNACGTRYacgtnnKMACGTCCAGT-ACGTNNNNNAC")
+	s, err := NewSeq(DNAredundant, _s)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	upper := bytes.ToUpper(_s)
+
+	p, err := NewPackedSeq(s)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if p.Len() != len(_s) {
+		t.Errorf("PackedSeq length error: %d", p.Len())
+	}
+	if !bytes.Equal(p.ToSeq().Seq, upper) {
+		t.Errorf("PackedSeq round trip error: %s", p.ToSeq().Seq)
+	}
+	for i, b := range upper {
+		if p.At(i) != b {
+			t.Errorf("PackedSeq.At(%d) error: %c, expected: %c", i, p.At(i), b)
+		}
+	}
+	if len(p.Runs()) != 8 {
+		t.Errorf("PackedSeq runs error: %v", p.Runs())
+	}
+
+	s2, _ := NewSeq(DNAredundant, upper)
+	for _, loc := range [][2]int{{1, -1}, {2, 5}, {3, 14}, {-10, -2}, {5, 5}, {20, 10}} {
+		sub := p.SubSeq(loc[0], loc[1])
+		if !bytes.Equal(sub.ToSeq().Seq, s2.SubSeq(loc[0], loc[1]).Seq) {
+			t.Errorf("PackedSeq.SubSeq(%d, %d) error: %s", loc[0], loc[1], sub.ToSeq().Seq)
+		}
+		p2, _ := NewPackedSeq(s2.SubSeq(loc[0], loc[1]))
+		if sub.Hash() != p2.Hash() {
+			t.Errorf("PackedSeq.Hash of SubSeq(%d, %d) error", loc[0], loc[1])
+		}
+	}
+	if p.SubSeq(1, 4).Hash() == p.SubSeq(2, 5).Hash() {
+		t.Errorf("PackedSeq.Hash collision")
+	}
+
+	rc := p.RevCom()
+	if !bytes.Equal(rc.ToSeq().Seq, s2.RevCom().Seq) {
+		t.Errorf("PackedSeq.RevCom error: %s", rc.ToSeq().Seq)
+	}
+	p2, _ := NewPackedSeq(s2.RevCom())
+	if rc.Hash() != p2.Hash() {
+		t.Errorf("PackedSeq.Hash of RevCom error")
+	}
+	for i := 0; i < rc.Len(); i++ {
+		if rc.Code(i) != p2.Code(i) {
+			t.Errorf("PackedSeq.RevCom code error at %d: %d, expected: %d", i, rc.Code(i), p2.Code(i))
+		}
+	}
+
+	r, _ := NewSeq(RNAredundant, []byte("ACGUNNUUA"))
+	pr, _ := NewPackedSeq(r)
+	if string(pr.ToSeq().Seq) != "ACGUNNUUA" || string(pr.RevCom().ToSeq().Seq) != "UAANNACGU" {
+		t.Errorf("PackedSeq of RNA error: %s, %s", pr.ToSeq().Seq, pr.RevCom().ToSeq().Seq)
+	}
+	if len(pr.Runs()) != 1 {
+		t.Errorf("PackedSeq runs of RNA error: %v", pr.Runs())
+	}
+
+	protein, _ := NewSeq(Protein, []byte("ACDEF"))
+	if _, err = NewPackedSeq(protein); err == nil {
+		t.Errorf("packing protein sequences should fail")
+	}
+}
+
+func TestPackedSeq4(t *testing.T) {
+	_s := []byte("NNACGTRYacgtnnKMACGTCCAGT-ACGTBDHVSWNAC")
+	s, err := NewSeq(DNAredundant, _s)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	upper := bytes.ToUpper(_s)
+
+	p, err := NewPackedSeq4(s)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(p.ToSeq().Seq, upper) {
+		t.Errorf("PackedSeq4 round trip error: %s", p.ToSeq().Seq)
+	}
+	for i, b := range upper {
+		if p.At(i) != b {
+			t.Errorf("PackedSeq4.At(%d) error: %c, expected: %c", i, p.At(i), b)
+		}
+	}
+
+	s2, _ := NewSeq(DNAredundant, upper)
+	for _, loc := range [][2]int{{1, -1}, {2, 5}, {3, 14}, {4, 14}, {-10, -2}, {5, 5}, {20, 10}} {
+		sub := p.SubSeq(loc[0], loc[1])
+		if !bytes.Equal(sub.ToSeq().Seq, s2.SubSeq(loc[0], loc[1]).Seq) {
+			t.Errorf("PackedSeq4.SubSeq(%d, %d) error: %s", loc[0], loc[1], sub.ToSeq().Seq)
+		}
+		p2, _ := NewPackedSeq4(s2.SubSeq(loc[0], loc[1]))
+		if sub.Hash() != p2.Hash() {
+			t.Errorf("PackedSeq4.Hash of SubSeq(%d, %d) error", loc[0], loc[1])
+		}
+	}
+
+	rc := p.RevCom()
+	if !bytes.Equal(rc.ToSeq().Seq, s2.RevCom().Seq) {
+		t.Errorf("PackedSeq4.RevCom error: %s", rc.ToSeq().Seq)
+	}
+
+	r, _ := NewSeq(RNAredundant, []byte("ACGUNNUUA"))
+	pr, _ := NewPackedSeq4(r)
+	if string(pr.ToSeq().Seq) != "ACGUNNUUA" || string(pr.RevCom().ToSeq().Seq) != "UAANNACGU" {
+		t.Errorf("PackedSeq4 of RNA error: %s, %s", pr.ToSeq().Seq, pr.RevCom().ToSeq().Seq)
+	}
+
+	s3, _ := NewSeqWithoutValidation(DNAredundant, []byte("ACGT.ACGT"))
+	if _, err = NewPackedSeq4(s3); err == nil {
+		t.Errorf("invalid letters should be reported")
+	}
+}
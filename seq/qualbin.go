@@ -0,0 +1,270 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidQualityBins means the quality bins are overlapped or out of range.
+var ErrInvalidQualityBins = errors.New("seq: invalid quality bins")
+
+// QualityTransformer transforms Phred quality values of a read in place,
+// e.g., binning and lossy compression.
+type QualityTransformer interface {
+	TransformPhred(qv []int) error
+}
+
+// QualityBin maps Phred qualities in the closed range [Min, Max] to Value.
+type QualityBin struct {
+	Min, Max int
+	Value    int
+}
+
+// QualityBins is a quality binning scheme, qualities not in any bins are not changed.
+type QualityBins struct {
+	bins  []QualityBin
+	table [maxPhred + 1]int
+}
+
+// NewQualityBins creates a quality binning scheme from non-overlapping bins,
+// all qualities and values should be in the range of [0, 93].
+func NewQualityBins(bins []QualityBin) (*QualityBins, error) {
+	b := &QualityBins{bins: bins}
+	for q := range b.table {
+		b.table[q] = -1
+	}
+	for _, bin := range bins {
+		if bin.Min < 0 || bin.Max > maxPhred || bin.Min > bin.Max ||
+			bin.Value < 0 || bin.Value > maxPhred {
+			return nil, fmt.Errorf("%w: [%d, %d] -> %d", ErrInvalidQualityBins, bin.Min, bin.Max, bin.Value)
+		}
+		for q := bin.Min; q <= bin.Max; q++ {
+			if b.table[q] >= 0 {
+				return nil, fmt.Errorf("%w: overlapped bins at %d", ErrInvalidQualityBins, q)
+			}
+			b.table[q] = bin.Value
+		}
+	}
+	return b, nil
+}
+
+func mustNewQualityBins(bins []QualityBin) *QualityBins {
+	b, err := NewQualityBins(bins)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// IlluminaQualityBins is the 8-level binning of Illumina, where the
+// qualities of no-calls (0 and 1) are not changed.
+var IlluminaQualityBins = mustNewQualityBins([]QualityBin{
+	{2, 9, 6},
+	{10, 19, 15},
+	{20, 24, 22},
+	{25, 29, 27},
+	{30, 34, 33},
+	{35, 39, 37},
+	{40, maxPhred, 40},
+})
+
+// NovaSeqQualityBins is the 4-level binning of Illumina NovaSeq,
+// with the values of 2, 12, 23 and 37.
+var NovaSeqQualityBins = mustNewQualityBins([]QualityBin{
+	{0, 2, 2},
+	{3, 14, 12},
+	{15, 30, 23},
+	{31, maxPhred, 37},
+})
+
+// NCBIQualityBins is the 4-level binning of NCBI, which is the same
+// 2/12/23/37 scheme as NovaSeqQualityBins.
+var NCBIQualityBins = NovaSeqQualityBins
+
+// Bins returns the bins.
+func (b *QualityBins) Bins() []QualityBin {
+	return b.bins
+}
+
+// TransformPhred bins the Phred quality values in place.
+func (b *QualityBins) TransformPhred(qv []int) error {
+	for i, q := range qv {
+		if q < 0 || q > maxPhred || b.table[q] < 0 {
+			continue
+		}
+		qv[i] = b.table[q]
+	}
+	return nil
+}
+
+// PBlock is the lossy P-block scheme (Cánovas et al., 2014), where runs of
+// qualities are replaced by one value with the absolute error <= MaxError.
+type PBlock struct {
+	MaxError int
+}
+
+// TransformPhred replaces runs of Phred quality values in place.
+func (p PBlock) TransformPhred(qv []int) error {
+	if p.MaxError < 0 {
+		return fmt.Errorf("seq: invalid maximum error of P-block: %d", p.MaxError)
+	}
+	transformBlocks(qv, func(q int) (int, int) {
+		return q - p.MaxError, q + p.MaxError
+	})
+	return nil
+}
+
+// RBlock is the lossy R-block scheme (Cánovas et al., 2014), where runs of
+// qualities are replaced by one value with the relative error <= MaxRelativeError.
+type RBlock struct {
+	MaxRelativeError float64
+}
+
+// TransformPhred replaces runs of Phred quality values in place.
+func (r RBlock) TransformPhred(qv []int) error {
+	if r.MaxRelativeError < 0 || r.MaxRelativeError >= 1 {
+		return fmt.Errorf("seq: invalid maximum relative error of R-block: %f", r.MaxRelativeError)
+	}
+	transformBlocks(qv, func(q int) (int, int) {
+		return int(math.Ceil(float64(q) * (1 - r.MaxRelativeError))),
+			int(math.Floor(float64(q) * (1 + r.MaxRelativeError)))
+	})
+	return nil
+}
+
+// transformBlocks greedily extends blocks of values as long as the ranges
+// of allowed values of all values in a block intersect, and replaces them
+// with the middle of the intersection.
+func transformBlocks(qv []int, allowed func(q int) (int, int)) {
+	var start, lo, hi, l, h, v int
+	for start < len(qv) {
+		lo, hi = allowed(qv[start])
+		end := start + 1
+		for ; end < len(qv); end++ {
+			l, h = allowed(qv[end])
+			if l < lo {
+				l = lo
+			}
+			if h > hi {
+				h = hi
+			}
+			if l > h {
+				break
+			}
+			lo, hi = l, h
+		}
+		v = (lo + hi) / 2
+		if v < 0 {
+			v = 0
+		}
+		for i := start; i < end; i++ {
+			qv[i] = v
+		}
+		start = end
+	}
+}
+
+// QualityTransformReport reports the effect of transforming qualities of a read.
+type QualityTransformReport struct {
+	// expected numbers of errors before and after the transformation, see Seq.ExpectedErrors
+	ExpectedErrorsBefore float64
+	ExpectedErrorsAfter  float64
+
+	Changed  int // number of bases with qualities changed
+	MaxDelta int // maximum absolute change of Phred qualities
+}
+
+// ExpectedErrorsChange returns the change of the expected number of errors.
+func (r QualityTransformReport) ExpectedErrorsChange() float64 {
+	return r.ExpectedErrorsAfter - r.ExpectedErrorsBefore
+}
+
+// TransformQuality transforms the qualities in place with the transformer,
+// and reports the changes. Qualities are decoded to Phred values with the
+// encoding (Unknown for Sanger) and encoded back with the same one, where
+// values out of the range of the encoding are truncated.
+func (seq *Seq) TransformQuality(t QualityTransformer, encoding QualityEncoding) (QualityTransformReport, error) {
+	var report QualityTransformReport
+	if len(seq.Qual) == 0 {
+		return report, ErrNoQuality
+	}
+	if encoding == Unknown {
+		encoding = Sanger
+	}
+	qv, err := encoding.PhredValues(seq.Qual)
+	if err != nil {
+		return report, err
+	}
+	qv2 := make([]int, len(qv))
+	copy(qv2, qv)
+	if err = t.TransformPhred(qv2); err != nil {
+		return report, err
+	}
+
+	var b byte
+	for i, q := range qv {
+		if qv2[i] == q {
+			continue
+		}
+		if b, err = encoding.encodePhred(qv2[i]); err != nil {
+			return report, err
+		}
+		if b != seq.Qual[i] {
+			seq.Qual[i] = b
+			report.Changed++
+		}
+	}
+
+	// the report is computed from the stored qualities, which might differ
+	// from the transformed values due to truncation of the encoding
+	stored, err := encoding.PhredValues(seq.Qual)
+	if err != nil {
+		return report, err
+	}
+	var d int
+	for i, q := range qv {
+		report.ExpectedErrorsBefore += phredErrorProb(q)
+		report.ExpectedErrorsAfter += phredErrorProb(stored[i])
+		if d = stored[i] - q; d < 0 {
+			d = -d
+		}
+		if d > report.MaxDelta {
+			report.MaxDelta = d
+		}
+	}
+	if report.Changed > 0 {
+		seq.QualValue = nil
+	}
+	return report, nil
+}
+
+// phredErrorProb returns the error probability of a Phred quality.
+func phredErrorProb(q int) float64 {
+	if q > 255 {
+		q = 255
+	}
+	return QUAL_MAP[q]
+}
+
+// encodePhred encodes a Phred quality value to a quality letter,
+// values out of the range of the encoding are truncated.
+func (qe QualityEncoding) encodePhred(q int) (byte, error) {
+	if q < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidPhredQuality, q)
+	}
+	offset := qe.Offset()
+	switch qe {
+	case Solexa:
+		s, _ := Phred2Solexa(float64(q))
+		q = int(math.Round(s))
+	case Illumina1p5:
+		if q < 2 {
+			q = 2 // read as 0 by PhredValues
+		}
+	}
+	if q > 126-offset {
+		q = 126 - offset
+	}
+	return byte(q + offset), nil
+}
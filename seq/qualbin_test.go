@@ -0,0 +1,95 @@
+package seq
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestQualityBins(t *testing.T) {
+	_, err := NewQualityBins([]QualityBin{{0, 10, 5}, {10, 20, 15}})
+	if !errors.Is(err, ErrInvalidQualityBins) {
+		t.Errorf("overlapped bins should be reported")
+	}
+
+	// Phred: 0 2 9 10 24 25 34 35 41
+	s, _ := NewSeqWithQualWithoutValidation(DNA, []byte("ACGTACGTA"), []byte("!#*+9:CDJ"))
+	report, err := s.TransformQuality(IlluminaQualityBins, Sanger)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(s.Qual) != "!''07<BFI" {
+		t.Errorf("Illumina binning error: %s", s.Qual)
+	}
+	if report.Changed != 8 || report.MaxDelta != 5 {
+		t.Errorf("report error: %+v", report)
+	}
+	if report.ExpectedErrorsChange() != report.ExpectedErrorsAfter-report.ExpectedErrorsBefore ||
+		report.ExpectedErrorsBefore <= 0 {
+		t.Errorf("report error: %+v", report)
+	}
+
+	// Illumina 1.3+, Phred: 0 10 30 40
+	s, _ = NewSeqWithQualWithoutValidation(DNA, []byte("ACGT"), []byte("@J^h"))
+	if _, err = s.TransformQuality(NCBIQualityBins, Illumina1p3); err != nil {
+		t.Error(err)
+		return
+	}
+	if string(s.Qual) != "BLWe" {
+		t.Errorf("NCBI binning error: %s", s.Qual)
+	}
+
+	// Illumina 1.5+, where 'B' (2) is read as 0, and binned values of 2 are stored as 0.
+	s, _ = NewSeqWithQualWithoutValidation(DNA, []byte("ACGT"), []byte("BBhh"))
+	report, err = s.TransformQuality(NovaSeqQualityBins, Illumina1p5)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	after, _ := s.ExpectedErrors(Illumina1p5)
+	if string(s.Qual) != "BBee" || report.Changed != 2 || report.MaxDelta != 3 ||
+		math.Abs(report.ExpectedErrorsAfter-after) > 1e-9 || report.ExpectedErrorsAfter < 2 {
+		t.Errorf("report should be computed from the stored qualities: %s, %+v", s.Qual, report)
+	}
+}
+
+func TestQualityBlocks(t *testing.T) {
+	qual := []byte("IIIHHGG5555?##")
+	for _, p := range []int{0, 1, 3} {
+		s, _ := NewSeqWithQualWithoutValidation(DNA, []byte("ACGTACGTACGTAC"), append([]byte{}, qual...))
+		report, err := s.TransformQuality(PBlock{MaxError: p}, Sanger)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if report.MaxDelta > p {
+			t.Errorf("P-block %d: error out of bound: %d", p, report.MaxDelta)
+		}
+		if p == 0 && report.Changed != 0 {
+			t.Errorf("P-block 0 should not change qualities: %s", s.Qual)
+		}
+		if p == 3 && string(s.Qual) != "HHHHHHH5555?##" {
+			t.Errorf("P-block 3: %s", s.Qual)
+		}
+	}
+
+	for _, r := range []float64{0.1, 0.25} {
+		s, _ := NewSeqWithQualWithoutValidation(DNA, []byte("ACGTACGTACGTAC"), append([]byte{}, qual...))
+		if _, err := s.TransformQuality(RBlock{MaxRelativeError: r}, Sanger); err != nil {
+			t.Error(err)
+			return
+		}
+		for i, q := range s.Qual {
+			q0 := float64(qual[i]) - 33
+			if math.Abs(float64(q)-33-q0) > r*q0 {
+				t.Errorf("R-block %f: error out of bound: %c -> %c", r, qual[i], q)
+			}
+		}
+	}
+
+	s, _ := NewSeqWithQualWithoutValidation(DNA, []byte("ACGT"), []byte("IIII"))
+	if _, err := s.TransformQuality(RBlock{MaxRelativeError: 1}, Sanger); err == nil {
+		t.Errorf("invalid R-block parameter should be reported")
+	}
+}
@@ -0,0 +1,236 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRecodingEvent means the recoding event is invalid, or it can not
+// be applied, e.g., the position is not at a codon boundary of the reading frame.
+var ErrInvalidRecodingEvent = errors.New("seq: invalid recoding event")
+
+// RecodingEvent represents a programmed ribosomal frameshift or
+// a context-dependent recoding of a codon, e.g., UGA -> U (selenocysteine)
+// and UAG -> O (pyrrolysine).
+//
+// Positions are 1-based, and on the strand being translated, i.e., for negative
+// frames, they are positions in the reverse complement sequence.
+type RecodingEvent struct {
+	// For a replacement, Position is the first base of the codon.
+	// For a frameshift, Position is the last base of the codon before the shift,
+	// and the next codon starts at Position+1+Shift, e.g., the -1 frameshift of
+	// join(266..13468,13468..21555) happens at 13468.
+	Position int
+
+	Shift     int  // frameshift, e.g., -1 or +1. 0 for a replacement
+	AminoAcid byte // replacement amino acid. 0 for a frameshift
+}
+
+func (e RecodingEvent) String() string {
+	if e.AminoAcid != 0 {
+		return fmt.Sprintf("%d:%c", e.Position, e.AminoAcid)
+	}
+	return fmt.Sprintf("%d:%+d", e.Position, e.Shift)
+}
+
+// AppliedRecodingEvent records where a recoding event was applied in the protein.
+type AppliedRecodingEvent struct {
+	RecodingEvent
+
+	// Residue is the 1-based position of the replaced amino acid,
+	// or of the last amino acid before the frameshift.
+	Residue int
+}
+
+// TranslateWithRecoding translates a DNA/RNA sequence with recoding events,
+// and returns the protein and the recoding events in the order of application.
+// Available frame: 1, 2, 3, -1, -2 ,-3. Nil opt is for default options.
+// ErrInvalidRecodingEvent is returned if any event can not be applied,
+// unless the translation is trimmed before the event.
+func (t *CodonTable) TranslateWithRecoding(sequence []byte, frame int, events []RecodingEvent, opt *TranslateOptions) ([]byte, []AppliedRecodingEvent, error) {
+	if len(sequence) < 3 {
+		return nil, nil, fmt.Errorf("seq: sequence too short to translate: %d", len(sequence))
+	}
+	if frame < -3 || frame > 3 || frame == 0 {
+		return nil, nil, fmt.Errorf("seq: invalid frame: %d. available: 1, 2, 3, -1, -2, -3", frame)
+	}
+	if opt == nil {
+		opt = &TranslateOptions{}
+	}
+
+	_events := make([]RecodingEvent, len(events))
+	copy(_events, events)
+	sort.SliceStable(_events, func(i, j int) bool { return _events[i].Position < _events[j].Position })
+	for _, e := range _events {
+		if e.Position < 1 || e.Position > len(sequence) || (e.Shift == 0) == (e.AminoAcid == 0) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidRecodingEvent, e)
+		}
+	}
+
+	if frame < 0 {
+		rc := make([]byte, len(sequence))
+		l := len(sequence) - 1
+		for i, b := range sequence {
+			rc[l-i] = complementNucleotide(b)
+		}
+		sequence = rc
+		frame = -frame
+	}
+
+	aas := make([]byte, 0, int((len(sequence)+2)/3))
+	applied := make([]AppliedRecodingEvent, 0, len(_events))
+	done := make([]bool, len(_events))
+	var aa byte
+	var err error
+	var codon []byte
+	var k, next int
+	first := true
+	var trimmed bool
+	for i := frame - 1; i+3 <= len(sequence); i = next {
+		codon = sequence[i : i+3]
+		aa, err = t.get(codon, opt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// replacement
+		replaced := false
+		for k = range _events {
+			if !done[k] && _events[k].AminoAcid != 0 && _events[k].Position == i+1 {
+				aa = _events[k].AminoAcid
+				done[k] = true
+				applied = append(applied, AppliedRecodingEvent{RecodingEvent: _events[k], Residue: len(aas) + 1})
+				replaced = true
+				break
+			}
+		}
+
+		if opt.MarkInitCodonAsM {
+			if first {
+				// convert amino acid of start codon to 'M'
+				if !replaced && t.isInitCodon(codon) {
+					aa = 'M'
+				}
+				first = false
+			} else if aa == '*' { // recoded stop codons are not stops
+				first = true
+			}
+		}
+
+		if opt.Trim && (aa == 'X' || aa == '*') {
+			trimmed = true
+			break
+		}
+		if opt.Clean && aa == '*' {
+			aa = 'X'
+		}
+
+		aas = append(aas, aa)
+
+		// frameshift
+		next = i + 3
+		for k = range _events {
+			if !done[k] && _events[k].Shift != 0 && _events[k].Position == i+3 {
+				next = i + 3 + _events[k].Shift
+				done[k] = true
+				applied = append(applied, AppliedRecodingEvent{RecodingEvent: _events[k], Residue: len(aas)})
+				break
+			}
+		}
+		if next <= i { // the ribosome can't move backward
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidRecodingEvent, _events[k])
+		}
+	}
+
+	if !trimmed {
+		for k = range _events {
+			if !done[k] {
+				return nil, nil, fmt.Errorf("%w: not applied: %s", ErrInvalidRecodingEvent, _events[k])
+			}
+		}
+	}
+	return aas, applied, nil
+}
+
+// TranslateWithRecoding translates the RNA/DNA to amino acid sequence with
+// recoding events, see CodonTable.TranslateWithRecoding.
+func (seq *Seq) TranslateWithRecoding(transl_table int, frame int, events []RecodingEvent, opt *TranslateOptions) (*Seq, []AppliedRecodingEvent, error) {
+	if !seq.isNucleotide() {
+		return nil, nil, fmt.Errorf("seq: only DNA/RNA sequence can call method Translate, the alphabet is %s", seq.Alphabet.String())
+	}
+	codonTable, ok := CodonTables[transl_table]
+	if !ok {
+		return nil, nil, fmt.Errorf("seq: invalid codon table: %d", transl_table)
+	}
+
+	aa, applied, err := codonTable.TranslateWithRecoding(seq.Seq, frame, events, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := NewSeqWithoutValidation(Protein, aa)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, applied, nil
+}
+
+// FrameshiftsFromSegments returns the frameshift events of a CDS composed of
+// segments, e.g., join(266..13468,13468..21555) of a ribosomal_slippage.
+// The positions of events are relative to segments[0].Start, i.e., for translating
+// the subsequence from segments[0].Start to the end of the last segment with frame 1.
+func FrameshiftsFromSegments(segments []Interval) ([]RecodingEvent, error) {
+	events := make([]RecodingEvent, 0, len(segments))
+	if len(segments) == 0 {
+		return events, nil
+	}
+	offset := segments[0].Start - 1
+	var shift int
+	for i := 1; i < len(segments); i++ {
+		if segments[i].Start <= segments[i-1].Start || segments[i].End < segments[i].Start {
+			return nil, fmt.Errorf("%w: unordered segments: %s, %s", ErrInvalidRecodingEvent, segments[i-1], segments[i])
+		}
+		shift = segments[i].Start - segments[i-1].End - 1
+		if shift == 0 {
+			continue
+		}
+		events = append(events, RecodingEvent{
+			Position: segments[i-1].End - offset,
+			Shift:    shift,
+		})
+	}
+	return events, nil
+}
+
+// aminoAcidCodes maps three-letter amino acid codes used in GenBank
+// transl_except qualifiers to one-letter codes.
+var aminoAcidCodes = map[string]byte{
+	"Ala": 'A', "Arg": 'R', "Asn": 'N', "Asp": 'D', "Cys": 'C',
+	"Gln": 'Q', "Glu": 'E', "Gly": 'G', "His": 'H', "Ile": 'I',
+	"Leu": 'L', "Lys": 'K', "Met": 'M', "Phe": 'F', "Pro": 'P',
+	"Ser": 'S', "Thr": 'T', "Trp": 'W', "Tyr": 'Y', "Val": 'V',
+	"Sec": 'U', "Pyl": 'O', "Asx": 'B', "Glx": 'Z', "Xle": 'J',
+	"TERM": '*', "OTHER": 'X',
+}
+
+var reTranslExcept = regexp.MustCompile(`^\(?\s*pos:\s*(\d+)\.\.(\d+)\s*,\s*aa:\s*(\w+)\s*\)?$`)
+
+// ParseTranslExcept parses the value of a GenBank transl_except qualifier,
+// e.g., "(pos:1002..1004,aa:Sec)". The position is relative to the sequence
+// start, and locations on the complement strand are not supported.
+func ParseTranslExcept(s string) (RecodingEvent, error) {
+	m := reTranslExcept.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return RecodingEvent{}, fmt.Errorf("%w: %s", ErrInvalidRecodingEvent, s)
+	}
+	start, _ := strconv.Atoi(m[1])
+	end, _ := strconv.Atoi(m[2])
+	aa, ok := aminoAcidCodes[m[3]]
+	if !ok || start < 1 || end-start > 2 || end < start {
+		return RecodingEvent{}, fmt.Errorf("%w: %s", ErrInvalidRecodingEvent, s)
+	}
+	return RecodingEvent{Position: start, AminoAcid: aa}, nil
+}
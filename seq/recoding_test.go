@@ -0,0 +1,92 @@
+package seq
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTranslateWithRecoding(t *testing.T) {
+	table := CodonTables[1]
+
+	// selenocysteine
+	s := []byte("ATGTGAGCCTGA")
+	aa, applied, err := table.TranslateWithRecoding(s, 1, []RecodingEvent{{Position: 4, AminoAcid: 'U'}}, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(aa) != "MUA*" || len(applied) != 1 || applied[0].Residue != 2 {
+		t.Errorf("recoding error: %s, %v", aa, applied)
+	}
+
+	// recoded stop codons do not reset the start codon marking
+	aa, _, err = table.TranslateWithRecoding([]byte("ATGTGACTGAAA"), 1,
+		[]RecodingEvent{{Position: 4, AminoAcid: 'U'}}, &TranslateOptions{MarkInitCodonAsM: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(aa) != "MULK" {
+		t.Errorf("recoding error with start codons marked: %s", aa)
+	}
+
+	// -1 frameshift: ATG AAA | AAC CCG -> M K N P
+	s = []byte("ATGAAAACCCG")
+	events, err := FrameshiftsFromSegments([]Interval{{1, 6}, {6, 11}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(events) != 1 || events[0].Position != 6 || events[0].Shift != -1 {
+		t.Errorf("frameshifts from segments error: %v", events)
+	}
+	aa, applied, err = table.TranslateWithRecoding(s, 1, events, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(aa) != "MKNP" || len(applied) != 1 || applied[0].Residue != 2 {
+		t.Errorf("frameshift error: %s, %v", aa, applied)
+	}
+
+	// +1 frameshift: ATG AAA c GCC -> M K A
+	aa, _, err = table.TranslateWithRecoding([]byte("ATGAAACGCC"), 1, []RecodingEvent{{Position: 6, Shift: 1}}, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(aa) != "MKA" {
+		t.Errorf("frameshift error: %s", aa)
+	}
+
+	// negative strand: reverse complement of ATGTGAGCC
+	aa, _, err = table.TranslateWithRecoding([]byte("GGCTCACAT"), -1, []RecodingEvent{{Position: 4, AminoAcid: 'U'}}, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(aa) != "MUA" {
+		t.Errorf("recoding error on negative strand: %s", aa)
+	}
+
+	// not at a codon boundary
+	_, _, err = table.TranslateWithRecoding([]byte("ATGTGAGCC"), 1, []RecodingEvent{{Position: 5, AminoAcid: 'U'}}, nil)
+	if !errors.Is(err, ErrInvalidRecodingEvent) {
+		t.Errorf("invalid recoding event should be reported")
+	}
+}
+
+func TestParseTranslExcept(t *testing.T) {
+	e, err := ParseTranslExcept("(pos:1002..1004,aa:Sec)")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if e.Position != 1002 || e.AminoAcid != 'U' {
+		t.Errorf("parsing transl_except error: %s", e)
+	}
+
+	if _, err = ParseTranslExcept("(pos:complement(1002..1004),aa:Sec)"); err == nil {
+		t.Errorf("unsupported transl_except should be reported")
+	}
+}
@@ -351,6 +351,58 @@ func (seq *Seq) RevComInplace() *Seq {
 	return seq.ReverseInplace().ComplementInplace()
 }
 
+// ToRNA returns a new RNA sequence converted from a DNA sequence, where T/t
+// are replaced with U/u, and the alphabet DNA/DNAredundant is changed to
+// RNA/RNAredundant. RNA sequences are simply cloned.
+func (seq *Seq) ToRNA() (*Seq, error) {
+	var alphabet *Alphabet
+	switch seq.Alphabet {
+	case DNA, RNA:
+		alphabet = RNA
+	case DNAredundant, RNAredundant:
+		alphabet = RNAredundant
+	default:
+		return nil, fmt.Errorf("seq: only DNA/RNA sequence can call method ToRNA, the alphabet is %s", seq.Alphabet.String())
+	}
+	s := seq.Clone()
+	s.Alphabet = alphabet
+	for i, b := range s.Seq {
+		switch b {
+		case 'T':
+			s.Seq[i] = 'U'
+		case 't':
+			s.Seq[i] = 'u'
+		}
+	}
+	return s, nil
+}
+
+// ToDNA returns a new DNA sequence converted from a RNA sequence, where U/u
+// are replaced with T/t, and the alphabet RNA/RNAredundant is changed to
+// DNA/DNAredundant. DNA sequences are simply cloned.
+func (seq *Seq) ToDNA() (*Seq, error) {
+	var alphabet *Alphabet
+	switch seq.Alphabet {
+	case DNA, RNA:
+		alphabet = DNA
+	case DNAredundant, RNAredundant:
+		alphabet = DNAredundant
+	default:
+		return nil, fmt.Errorf("seq: only DNA/RNA sequence can call method ToDNA, the alphabet is %s", seq.Alphabet.String())
+	}
+	s := seq.Clone()
+	s.Alphabet = alphabet
+	for i, b := range s.Seq {
+		switch b {
+		case 'U':
+			s.Seq[i] = 'T'
+		case 'u':
+			s.Seq[i] = 't'
+		}
+	}
+	return s, nil
+}
+
 // Reverse a sequence
 func (seq *Seq) Reverse() *Seq {
 	return seq.Clone().ReverseInplace()
@@ -684,7 +736,7 @@ func Degenerate2Seqs(s []byte) (dseqs [][]byte, err error) {
 // If option markInitCodonAsM is true, initial codon at beginning will be represented as 'M'.
 func (seq *Seq) Translate(transl_table int, frame int, trim bool, clean bool, allowUnknownCodon bool, markInitCodonAsM bool) (*Seq, error) {
 	if !(seq.Alphabet.String() == "DNA" || seq.Alphabet.String() == "DNAredundant" || seq.Alphabet.String() == "RNA" || seq.Alphabet.String() == "RNAredundant") {
-		return nil, fmt.Errorf("seq: only DNA/RNA sequence can all method Translate, the alphabet is %s", seq.Alphabet.String())
+		return nil, fmt.Errorf("seq: only DNA/RNA sequence can call method Translate, the alphabet is %s", seq.Alphabet.String())
 	}
 	var codonTable *CodonTable
 	var ok bool
@@ -707,6 +759,25 @@ func (seq *Seq) Translate(transl_table int, frame int, trim bool, clean bool, al
 	return t, nil
 }
 
+// TranslateWithOptions translates the RNA/DNA to amino acid sequence with options,
+// see TranslateOptions.
+// Available frame: 1, 2, 3, -1, -2 ,-3.
+func (seq *Seq) TranslateWithOptions(transl_table int, frame int, opt *TranslateOptions) (*Seq, error) {
+	if !(seq.Alphabet.String() == "DNA" || seq.Alphabet.String() == "DNAredundant" || seq.Alphabet.String() == "RNA" || seq.Alphabet.String() == "RNAredundant") {
+		return nil, fmt.Errorf("seq: only DNA/RNA sequence can call method Translate, the alphabet is %s", seq.Alphabet.String())
+	}
+	codonTable, ok := CodonTables[transl_table]
+	if !ok {
+		return nil, fmt.Errorf("seq: invalid codon table: %d", transl_table)
+	}
+
+	aa, err := codonTable.TranslateWithOptions(seq.Seq, frame, opt)
+	if err != nil {
+		return nil, err
+	}
+	return NewSeqWithoutValidation(Protein, aa)
+}
+
 // ParseQual parses sequence quality, asciiBase = 33 for Phred+33.
 func (seq *Seq) ParseQual(asciiBase int) {
 	if len(seq.Qual) == 0 {
@@ -744,6 +815,30 @@ func (seq *Seq) AvgQual(asciiBase int) float64 {
 	return -10 * math.Log10(sum/float64(len(seq.QualValue)))
 }
 
+// MeanErrorProb returns the mean error probability of all bases,
+// which is more suitable than the mean Phred quality for long reads.
+func (seq *Seq) MeanErrorProb(encoding QualityEncoding) (float64, error) {
+	if len(seq.Qual) == 0 {
+		return 0, ErrNoQuality
+	}
+	ee, err := seq.ExpectedErrors(encoding)
+	if err != nil {
+		return 0, err
+	}
+	return ee / float64(len(seq.Qual)), nil
+}
+
+// MeanQScore returns the Phred-scaled mean error probability, i.e.,
+// the read quality score reported by Nanopore basecallers.
+// Different from AvgQual, the encoding is supported.
+func (seq *Seq) MeanQScore(encoding QualityEncoding) (float64, error) {
+	p, err := seq.MeanErrorProb(encoding)
+	if err != nil {
+		return 0, err
+	}
+	return -10 * math.Log10(p), nil
+}
+
 // Slider returns a function for sliding the sequence.
 // Circular is for circular genome, and it overides greedy.
 // If not circular and greedy is true, last fragment shorter than window will be returned.
@@ -2,6 +2,7 @@ package seq
 
 import (
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -116,3 +117,70 @@ func TestSubSeqInplace(t *testing.T) {
 		t.Error(fmt.Printf("subseq error"))
 	}
 }
+
+func TestDNARNAConversion(t *testing.T) {
+	dna, _ := NewSeq(DNAredundant, []byte("ATGtcrN"))
+	rna, err := dna.ToRNA()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if rna.Alphabet != RNAredundant || string(rna.Seq) != "AUGucrN" {
+		t.Errorf("ToRNA error: %s %s", rna.Alphabet, rna.Seq)
+	}
+	if string(rna.RevCom().Seq) != "NygaCAU" {
+		t.Errorf("revcom of RNA error: %s", rna.RevCom().Seq)
+	}
+	dna2, _ := rna.ToDNA()
+	if dna2.Alphabet != DNAredundant || string(dna2.Seq) != string(dna.Seq) {
+		t.Errorf("ToDNA error: %s %s", dna2.Alphabet, dna2.Seq)
+	}
+
+	p, _ := NewSeq(Protein, []byte("MK"))
+	if _, err = p.ToRNA(); err == nil {
+		t.Errorf("converting protein to RNA should fail")
+	}
+}
+
+func TestTranslateRNA(t *testing.T) {
+	rna, _ := NewSeq(RNA, []byte("CAUAUAUGA"))
+	p, err := rna.Translate(1, -1, false, false, false, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	dna, _ := rna.ToDNA()
+	p2, _ := dna.Translate(1, -1, false, false, false, true)
+	if string(p.Seq) != "SYM" || string(p.Seq) != string(p2.Seq) {
+		t.Errorf("translating RNA on negative strand error: %s, DNA: %s", p.Seq, p2.Seq)
+	}
+
+	// UUG is an alternative initiation codon
+	rna, _ = NewSeq(RNA, []byte("UUGAAA"))
+	p, _ = rna.Translate(1, 1, false, false, false, true)
+	if string(p.Seq) != "MK" {
+		t.Errorf("translating RNA error: %s", p.Seq)
+	}
+}
+
+func TestMeanQScore(t *testing.T) {
+	// Phred 10 and 40, i.e., 0.1 and 0.0001
+	s, _ := NewSeqWithQual(DNAredundant, []byte("ACGT"), []byte("++II"))
+	p, err := s.MeanErrorProb(Sanger)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if math.Abs(p-0.05005) > 1e-9 {
+		t.Errorf("mean error probability error: %f", p)
+	}
+	q, _ := s.MeanQScore(Sanger)
+	if math.Abs(q-13.0060) > 1e-4 {
+		t.Errorf("mean qscore error: %f", q)
+	}
+
+	s, _ = NewSeq(DNAredundant, []byte("ACGT"))
+	if _, err = s.MeanQScore(Sanger); err != ErrNoQuality {
+		t.Errorf("no quality should be reported")
+	}
+}
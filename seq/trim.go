@@ -0,0 +1,263 @@
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// PhredValues returns Phred quality values of a quality string.
+// Solexa qualities are converted to Phred ones, and the value 2 of
+// Illumina 1.5+ (the Read Segment Quality Control Indicator) is treated as 0.
+// Unknown encoding is treated as Sanger (Phred+33).
+func (qe QualityEncoding) PhredValues(quality []byte) ([]int, error) {
+	if qe == Unknown {
+		qe = Sanger
+	}
+	offset := qe.Offset()
+	qv := make([]int, len(quality))
+	var q int
+	var p float64
+	var err error
+	for i, b := range quality {
+		q = int(b) - offset
+		switch qe {
+		case Solexa:
+			p, err = Solexa2Phred(float64(q))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %c", err, b)
+			}
+			q = int(math.Round(p))
+		case Illumina1p5:
+			if q == 2 {
+				q = 0
+			}
+		}
+		if q < 0 {
+			return nil, fmt.Errorf("%w: %c (%s)", ErrInvalidPhredQuality, b, qe)
+		}
+		qv[i] = q
+	}
+	return qv, nil
+}
+
+// TrimOptions contains the options of quality trimming.
+// Steps are applied in the order of Leading, Trailing, Window and BWAQual,
+// and zero values mean the steps are disabled.
+type TrimOptions struct {
+	Encoding QualityEncoding // quality encoding, Unknown for Sanger (Phred+33)
+
+	// Leading removes bases with qualities below it from the 5' end,
+	// like LEADING of Trimmomatic.
+	Leading int
+	// Trailing removes bases with qualities below it from the 3' end,
+	// like TRAILING of Trimmomatic.
+	Trailing int
+
+	// Window and WindowQual are for the sliding window trimming, like
+	// SLIDINGWINDOW of Trimmomatic. Scanning from the 5' end, the sequence is
+	// cut at the first window of which the average quality is below WindowQual,
+	// while leading bases of the window with qualities >= WindowQual are kept.
+	Window     int
+	WindowQual float64
+
+	// BWAQual trims the 3' end like the option -q of "bwa aln", i.e., cutting
+	// at the position maximizing the sum of (BWAQual - quality) to the end.
+	BWAQual int
+}
+
+// ErrNoQuality means the sequence has no quality.
+var ErrNoQuality = errors.New("seq: no quality")
+
+// QualityTrimRegion returns the region to keep after quality trimming.
+// Returned start and end are 0-based and half-open, start == end if the
+// whole sequence should be trimmed. The sequence is not changed.
+// Nil opt means no trimming steps, i.e., only qualities are checked.
+func (seq *Seq) QualityTrimRegion(opt *TrimOptions) (start, end int, err error) {
+	if opt == nil {
+		opt = &TrimOptions{}
+	}
+	if len(seq.Qual) == 0 {
+		return 0, len(seq.Seq), ErrNoQuality
+	}
+	if len(seq.Qual) != len(seq.Seq) {
+		return 0, len(seq.Seq), fmt.Errorf("seq: unmatched length of sequence (%d) and quality (%d)", len(seq.Seq), len(seq.Qual))
+	}
+	qv, err := opt.Encoding.PhredValues(seq.Qual)
+	if err != nil {
+		return 0, len(seq.Seq), err
+	}
+	start, end = 0, len(qv)
+
+	if opt.Leading > 0 {
+		for start < end && qv[start] < opt.Leading {
+			start++
+		}
+	}
+	if opt.Trailing > 0 {
+		for end > start && qv[end-1] < opt.Trailing {
+			end--
+		}
+	}
+
+	if opt.Window > 0 && end-start >= opt.Window {
+		required := opt.WindowQual * float64(opt.Window)
+		var sum int
+		for i := start; i < start+opt.Window; i++ {
+			sum += qv[i]
+		}
+		for i := start; i+opt.Window <= end; i++ {
+			if i > start {
+				sum += qv[i+opt.Window-1] - qv[i-1]
+			}
+			if float64(sum) < required {
+				j := i
+				for j < i+opt.Window && float64(qv[j]) >= opt.WindowQual {
+					j++
+				}
+				end = j
+				break
+			}
+		}
+	}
+
+	if opt.BWAQual > 0 {
+		var s, maxS int
+		maxI := end
+		for i := end - 1; i >= start; i-- {
+			s += opt.BWAQual - qv[i]
+			if s < 0 {
+				break
+			}
+			if s > maxS {
+				maxS, maxI = s, i
+			}
+		}
+		end = maxI
+	}
+
+	if end < start {
+		end = start
+	}
+	return start, end, nil
+}
+
+// TrimByQuality trims the sequence and quality in place, and returns the
+// 0-based half-open region kept, see QualityTrimRegion.
+func (seq *Seq) TrimByQuality(opt *TrimOptions) (start, end int, err error) {
+	start, end, err = seq.QualityTrimRegion(opt)
+	if err != nil {
+		return start, end, err
+	}
+	seq.keepRegion(start, end)
+	return start, end, nil
+}
+
+// keepRegion keeps the 0-based half-open region of the sequence and quality in place.
+func (seq *Seq) keepRegion(start, end int) {
+	seq.Seq = seq.Seq[start:end]
+	if len(seq.Qual) >= end {
+		seq.Qual = seq.Qual[start:end]
+	}
+	if len(seq.QualValue) >= end {
+		seq.QualValue = seq.QualValue[start:end]
+	} else {
+		seq.QualValue = nil
+	}
+}
+
+// ExpectedErrors returns the expected number of errors of the sequence,
+// i.e., the sum of error probabilities of all bases.
+func (seq *Seq) ExpectedErrors(encoding QualityEncoding) (float64, error) {
+	qv, err := encoding.PhredValues(seq.Qual)
+	if err != nil {
+		return 0, err
+	}
+	var ee float64
+	for _, q := range qv {
+		if q > 255 {
+			q = 255
+		}
+		ee += QUAL_MAP[q]
+	}
+	return ee, nil
+}
+
+// NFraction returns the fraction of N/n in the sequence.
+func (seq *Seq) NFraction() float64 {
+	if len(seq.Seq) == 0 {
+		return 0
+	}
+	var n int
+	for _, b := range seq.Seq {
+		if b == 'N' || b == 'n' {
+			n++
+		}
+	}
+	return float64(n) / float64(len(seq.Seq))
+}
+
+// FilterOptions contains the options of read filtering,
+// zero values mean no limits.
+type FilterOptions struct {
+	Encoding QualityEncoding // quality encoding, Unknown for Sanger (Phred+33)
+
+	MinLen int // minimum sequence length
+	// MaxExpectedErrors is the maximum expected number of errors,
+	// it's not checked for sequences without qualities.
+	MaxExpectedErrors float64
+	MaxNFraction      float64 // maximum fraction of N
+}
+
+// FilterResult is the result of filtering a sequence.
+type FilterResult int
+
+const (
+	// FilterPass means the sequence passes all filters.
+	FilterPass FilterResult = iota
+	// FilterTooShort means the sequence is shorter than MinLen.
+	FilterTooShort
+	// FilterTooManyErrors means the expected number of errors exceeds MaxExpectedErrors.
+	FilterTooManyErrors
+	// FilterTooManyNs means the fraction of N exceeds MaxNFraction.
+	FilterTooManyNs
+)
+
+func (r FilterResult) String() string {
+	switch r {
+	case FilterPass:
+		return "pass"
+	case FilterTooShort:
+		return "too short"
+	case FilterTooManyErrors:
+		return "too many expected errors"
+	case FilterTooManyNs:
+		return "too many Ns"
+	}
+	return fmt.Sprintf("FilterResult(%d)", int(r))
+}
+
+// Filter checks the sequence with the filters in the order of
+// MinLen, MaxNFraction and MaxExpectedErrors, and returns the first failure.
+// Nil opt means no filters, i.e., all sequences pass.
+func (seq *Seq) Filter(opt *FilterOptions) (FilterResult, error) {
+	if opt == nil {
+		return FilterPass, nil
+	}
+	if opt.MinLen > 0 && len(seq.Seq) < opt.MinLen {
+		return FilterTooShort, nil
+	}
+	if opt.MaxNFraction > 0 && seq.NFraction() > opt.MaxNFraction {
+		return FilterTooManyNs, nil
+	}
+	if opt.MaxExpectedErrors > 0 && len(seq.Qual) > 0 {
+		ee, err := seq.ExpectedErrors(opt.Encoding)
+		if err != nil {
+			return FilterPass, err
+		}
+		if ee > opt.MaxExpectedErrors {
+			return FilterTooManyErrors, nil
+		}
+	}
+	return FilterPass, nil
+}
@@ -0,0 +1,103 @@
+package seq
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTrimByQuality(t *testing.T) {
+	type testCase struct {
+		seq, qual string
+		opt       TrimOptions
+		expected  string
+	}
+	cases := []testCase{
+		{"ACGTACGTA", "++5?I?5++", TrimOptions{Leading: 20, Trailing: 20}, "GTACG"},
+		{"ACGTACGTA", "+++++++++", TrimOptions{Leading: 20, Trailing: 20}, ""},
+		{"ACGTACGTACGTA", "IIIIII+I+++++", TrimOptions{Window: 4, WindowQual: 20}, "ACGTAC"},
+		{"ACGTACGTACGTA", "IIIII5I+++++I", TrimOptions{Window: 4, WindowQual: 20}, "ACGTACG"},
+		{"ACGTACGTA", "IIIII5+!+", TrimOptions{BWAQual: 20}, "ACGTAC"},
+		{"ACGTACGTA", "IIIIIIIII", TrimOptions{Leading: 20, Trailing: 20, Window: 4, WindowQual: 20, BWAQual: 20}, "ACGTACGTA"},
+		// Illumina 1.3+, Phred+64
+		{"ACGTACGTA", "JJTh^hTJJ", TrimOptions{Encoding: Illumina1p3, Leading: 20, Trailing: 20}, "GTACG"},
+	}
+
+	for i, c := range cases {
+		s, _ := NewSeqWithQual(DNA, []byte(c.seq), []byte(c.qual))
+		start, end, err := s.TrimByQuality(&c.opt)
+		if err != nil {
+			t.Errorf("case %d: %s", i, err)
+			continue
+		}
+		if string(s.Seq) != c.expected || len(s.Qual) != len(s.Seq) {
+			t.Errorf("case %d: trimming error: %s, expected: %s", i, s.Seq, c.expected)
+		}
+		if c.seq[start:end] != c.expected {
+			t.Errorf("case %d: trimming region error: [%d, %d)", i, start, end)
+		}
+	}
+
+	s, _ := NewSeqWithQual(DNA, []byte("ACGT"), []byte("!!II"))
+	if start, end, err := s.TrimByQuality(nil); err != nil || start != 0 || end != 4 {
+		t.Errorf("trimming with nil options error: [%d, %d), %v", start, end, err)
+	}
+
+	s, _ = NewSeq(DNA, []byte("ACGT"))
+	if _, _, err := s.TrimByQuality(&TrimOptions{Leading: 20}); !errors.Is(err, ErrNoQuality) {
+		t.Errorf("sequence without quality should be reported")
+	}
+}
+
+func TestPhredValues(t *testing.T) {
+	qv, err := Solexa.PhredValues([]byte(";@h"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if qv[0] != 1 || qv[1] != 3 || qv[2] != 40 {
+		t.Errorf("Solexa to Phred error: %v", qv)
+	}
+
+	if _, err = Sanger.PhredValues([]byte(" ")); !errors.Is(err, ErrInvalidPhredQuality) {
+		t.Errorf("invalid quality should be reported")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	s, _ := NewSeqWithQual(DNAredundant, []byte("ACGTNNACGT"), []byte("!!IIIIIIII"))
+
+	ee, err := s.ExpectedErrors(Sanger)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if math.Abs(ee-2.0008) > 1e-6 {
+		t.Errorf("expected errors error: %f", ee)
+	}
+
+	cases := []struct {
+		opt      FilterOptions
+		expected FilterResult
+	}{
+		{FilterOptions{}, FilterPass},
+		{FilterOptions{MinLen: 11}, FilterTooShort},
+		{FilterOptions{MinLen: 10, MaxNFraction: 0.1}, FilterTooManyNs},
+		{FilterOptions{MaxNFraction: 0.2, MaxExpectedErrors: 2}, FilterTooManyErrors},
+		{FilterOptions{MaxNFraction: 0.2, MaxExpectedErrors: 2.1}, FilterPass},
+	}
+	var r FilterResult
+	for i, c := range cases {
+		r, err = s.Filter(&c.opt)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if r != c.expected {
+			t.Errorf("case %d: filter error: %s, expected: %s", i, r, c.expected)
+		}
+	}
+	if r, err = s.Filter(nil); err != nil || r != FilterPass {
+		t.Errorf("filtering with nil options error: %s, %v", r, err)
+	}
+}
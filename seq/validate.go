@@ -0,0 +1,234 @@
+package seq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidLetters means invalid letters remain after fixing the sequence.
+var ErrInvalidLetters = errors.New("seq: invalid letters")
+
+// InvalidLetter records the count and the first position of an invalid letter.
+type InvalidLetter struct {
+	Letter byte
+	Count  int
+	First  int // 0-based position of the first occurrence
+}
+
+// ValidationReport is the detailed report of validating a sequence.
+type ValidationReport struct {
+	Alphabet *Alphabet
+	Length   int             // sequence length
+	Invalid  []InvalidLetter // invalid letters, sorted by the first position
+}
+
+// Valid tells whether the sequence is valid.
+func (r *ValidationReport) Valid() bool {
+	return len(r.Invalid) == 0
+}
+
+// Total returns the total number of invalid letters.
+func (r *ValidationReport) Total() int {
+	var n int
+	for _, l := range r.Invalid {
+		n += l.Count
+	}
+	return n
+}
+
+func (r *ValidationReport) String() string {
+	if r.Valid() {
+		return fmt.Sprintf("valid %s sequence of %d letters", r.Alphabet, r.Length)
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d invalid %s letters in %d letters:", r.Total(), r.Alphabet, r.Length)
+	for _, l := range r.Invalid {
+		fmt.Fprintf(&b, " %q (count: %d, first: %d)", l.Letter, l.Count, l.First)
+	}
+	return b.String()
+}
+
+// Validate checks all the letters of a sequence, and returns the counts and
+// first positions of invalid letters. Like IsValid, long sequences
+// (>= ValidSeqLengthThreshold) are checked in parallel.
+func (a *Alphabet) Validate(s []byte) *ValidationReport {
+	r := &ValidationReport{Alphabet: a, Length: len(s)}
+	if len(s) == 0 || a == nil || a.isUnlimit {
+		return r
+	}
+
+	var counts [256]int
+	var firsts [256]int
+	for i := range firsts {
+		firsts[i] = -1
+	}
+
+	l := len(s)
+	if l < ValidSeqLengthThreshold || ValidSeqThreads <= 1 {
+		a.countInvalid(s, 0, &counts, &firsts)
+	} else {
+		chunkSize := l/ValidSeqThreads + 1
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for start := 0; start < l; start += chunkSize {
+			end := start + chunkSize
+			if end > l {
+				end = l
+			}
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				var _counts [256]int
+				var _firsts [256]int
+				for i := range _firsts {
+					_firsts[i] = -1
+				}
+				a.countInvalid(s[start:end], start, &_counts, &_firsts)
+
+				mu.Lock()
+				for i, c := range _counts {
+					if c == 0 {
+						continue
+					}
+					counts[i] += c
+					if firsts[i] < 0 || _firsts[i] < firsts[i] {
+						firsts[i] = _firsts[i]
+					}
+				}
+				mu.Unlock()
+			}(start, end)
+		}
+		wg.Wait()
+	}
+
+	for i, c := range counts {
+		if c > 0 {
+			r.Invalid = append(r.Invalid, InvalidLetter{Letter: byte(i), Count: c, First: firsts[i]})
+		}
+	}
+	sort.Slice(r.Invalid, func(i, j int) bool { return r.Invalid[i].First < r.Invalid[j].First })
+	return r
+}
+
+func (a *Alphabet) countInvalid(s []byte, offset int, counts *[256]int, firsts *[256]int) {
+	var j int
+	for i, b := range s {
+		j = int(b)
+		if j >= len(a.pairLetters) || a.pairLetters[j] == 0 {
+			if counts[b] == 0 {
+				firsts[b] = offset + i
+			}
+			counts[b]++
+		}
+	}
+}
+
+// FixPolicy decides how invalid letters are fixed, policies could be combined,
+// e.g., FixUppercase|FixReplace. FixUppercase is applied first, and FixReplace
+// has a higher priority than FixDrop.
+type FixPolicy int
+
+const (
+	// FixUppercase converts invalid lower-case letters to upper-case ones if they are valid.
+	FixUppercase FixPolicy = 1 << iota
+	// FixReplace replaces invalid letters with a replacement letter, e.g., N or X.
+	FixReplace
+	// FixDrop removes invalid letters, along with their quality values.
+	FixDrop
+)
+
+func (p FixPolicy) String() string {
+	if p == 0 {
+		return "none"
+	}
+	names := make([]string, 0, 3)
+	if p&FixUppercase > 0 {
+		names = append(names, "uppercase")
+	}
+	if p&FixReplace > 0 {
+		names = append(names, "replace")
+	}
+	if p&FixDrop > 0 {
+		names = append(names, "drop")
+	}
+	return strings.Join(names, "|")
+}
+
+// DefaultReplacement returns the default letter for replacing invalid letters,
+// i.e., X for Protein, and N for others. 0 is returned if it's not valid.
+func (a *Alphabet) DefaultReplacement() byte {
+	if a == Protein {
+		return 'X'
+	}
+	for _, b := range []byte{'N', 'n', 'X', 'x'} {
+		if a.IsValidLetter(b) {
+			return b
+		}
+	}
+	return 0
+}
+
+// Fix fixes invalid letters of the sequence in place with the policy,
+// and returns the validation report before fixing.
+// The quality is also updated for FixDrop. 0 for replacement means the
+// default one (see Alphabet.DefaultReplacement).
+// ErrInvalidLetters is returned if any invalid letters remain.
+func (seq *Seq) Fix(policy FixPolicy, replacement byte) (*ValidationReport, error) {
+	a := seq.Alphabet
+	report := a.Validate(seq.Seq)
+	if report.Valid() {
+		return report, nil
+	}
+
+	if policy&FixReplace > 0 {
+		if replacement == 0 {
+			replacement = a.DefaultReplacement()
+		}
+		if replacement == 0 || !a.IsValidLetter(replacement) {
+			return report, fmt.Errorf("%w: invalid replacement letter for %s: %q", ErrInvalidLetters, a, replacement)
+		}
+	}
+
+	hasQual := len(seq.Qual) == len(seq.Seq)
+	var invalid [256]bool
+	for _, l := range report.Invalid {
+		invalid[l.Letter] = true
+	}
+
+	var j, remain int
+	for i, b := range seq.Seq {
+		if invalid[b] {
+			switch {
+			case policy&FixUppercase > 0 && b >= 'a' && b <= 'z' && a.IsValidLetter(b-32):
+				b -= 32
+			case policy&FixReplace > 0:
+				b = replacement
+			case policy&FixDrop > 0:
+				continue
+			default:
+				remain++
+			}
+		}
+		seq.Seq[j] = b
+		if hasQual {
+			seq.Qual[j] = seq.Qual[i]
+		}
+		j++
+	}
+	if j < len(seq.Seq) {
+		seq.Seq = seq.Seq[:j]
+		if hasQual {
+			seq.Qual = seq.Qual[:j]
+		}
+		seq.QualValue = nil
+	}
+
+	if remain > 0 {
+		return report, fmt.Errorf("%w: %d %s letters remain after fixing", ErrInvalidLetters, remain, a)
+	}
+	return report, nil
+}
@@ -0,0 +1,67 @@
+package seq
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	r := DNA.Validate([]byte("ACGTuACZZu"))
+	if r.Valid() || r.Total() != 4 || len(r.Invalid) != 2 {
+		t.Errorf("validation report error: %s", r)
+		return
+	}
+	if r.Invalid[0].Letter != 'u' || r.Invalid[0].Count != 2 || r.Invalid[0].First != 4 ||
+		r.Invalid[1].Letter != 'Z' || r.Invalid[1].First != 7 {
+		t.Errorf("validation report error: %s", r)
+	}
+
+	// parallel
+	s := append(bytes.Repeat([]byte("ACGT"), ValidSeqLengthThreshold), 'u')
+	s = append(s, bytes.Repeat([]byte("ACGT"), ValidSeqLengthThreshold)...)
+	s = append(s, 'u')
+	r = DNA.Validate(s)
+	if r.Total() != 2 || r.Invalid[0].First != ValidSeqLengthThreshold*4 {
+		t.Errorf("validation report error for long sequence: %s", r)
+	}
+
+	if !DNA.Validate([]byte("ACGTN")).Valid() {
+		t.Errorf("valid sequence should pass")
+	}
+}
+
+func TestFix(t *testing.T) {
+	a, _ := NewAlphabetBuilder("DNAupper").
+		AddSymbols(SymbolLetter, []byte("ACGT"), []byte("TGCA")).
+		AddSymbols(SymbolAmbiguous, []byte("N"), nil).
+		Build()
+
+	s := &Seq{Alphabet: a, Seq: []byte("ACgtZA"), Qual: []byte("ABCDEF")}
+	report, err := s.Fix(FixUppercase|FixDrop, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if report.Total() != 3 || string(s.Seq) != "ACGTA" || string(s.Qual) != "ABCDF" {
+		t.Errorf("fix error: %s, %s", s.Seq, s.Qual)
+	}
+
+	s = &Seq{Alphabet: a, Seq: []byte("ACgtZA")}
+	s.Fix(FixReplace, 0)
+	if string(s.Seq) != "ACNNNA" {
+		t.Errorf("fix error: %s", s.Seq)
+	}
+
+	s = &Seq{Alphabet: a, Seq: []byte("ACgtZA")}
+	_, err = s.Fix(FixUppercase, 0)
+	if !errors.Is(err, ErrInvalidLetters) || string(s.Seq) != "ACGTZA" {
+		t.Errorf("remaining invalid letters should be reported: %s", s.Seq)
+	}
+
+	p := &Seq{Alphabet: Protein, Seq: []byte("MK#L")}
+	p.Fix(FixReplace, 0)
+	if string(p.Seq) != "MKXL" {
+		t.Errorf("fix error: %s", p.Seq)
+	}
+}
@@ -0,0 +1,244 @@
+package fastx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/shenwei356/bio/seq"
+	"github.com/zeebo/wyhash"
+)
+
+// DedupKey is the key for deduplicating records.
+type DedupKey int
+
+const (
+	// DedupBySeq deduplicates records by sequences.
+	DedupBySeq DedupKey = iota
+	// DedupByID deduplicates records by IDs.
+	DedupByID
+	// DedupByName deduplicates records by full names.
+	DedupByName
+)
+
+func (k DedupKey) String() string {
+	switch k {
+	case DedupBySeq:
+		return "seq"
+	case DedupByID:
+		return "id"
+	case DedupByName:
+		return "name"
+	}
+	return fmt.Sprintf("DedupKey(%d)", int(k))
+}
+
+// DedupOptions contains the options of deduplicating records.
+type DedupOptions struct {
+	By DedupKey
+
+	// BothStrands treats nucleotide sequences and their reverse complements
+	// as duplicates, only for DedupBySeq.
+	BothStrands bool
+	IgnoreCase  bool // case-insensitive for sequences, IDs and names
+
+	// BloomExpectedItems > 0 enables the bounded-memory mode, where keys are
+	// stored in a Bloom filter for the expected number of distinct records with
+	// the false positive rate of BloomFPR (0 for 0.001), and duplicate groups
+	// are not tracked. Unique records could be reported as duplicates with
+	// the false positive rate.
+	BloomExpectedItems uint64
+	BloomFPR           float64
+
+	// TrackGroups records IDs of all records of duplicate groups.
+	TrackGroups bool
+}
+
+// DuplicateGroup is a group of duplicate records.
+type DuplicateGroup struct {
+	// IDs of records in the order of reading, the first one is kept by the
+	// Deduplicator, while you can choose another representative.
+	IDs []string
+}
+
+// Deduplicator removes duplicate records in streaming mode, where only
+// the 64-bit hashes of the keys are stored, so the memory is proportional
+// to the number of distinct records instead of the data size.
+// Hash collisions are negligible for less than billions of records.
+//
+// Deduplicator is not safe for concurrent use.
+type Deduplicator struct {
+	opt DedupOptions
+
+	seen  map[uint64]int // hash -> index of group, -1 for no tracking
+	bloom *bloomFilter
+
+	groups   []*DuplicateGroup
+	nRecords uint64
+	nDups    uint64
+}
+
+// NewDeduplicator creates a Deduplicator. Nil opt is for deduplicating by sequences.
+func NewDeduplicator(opt *DedupOptions) (*Deduplicator, error) {
+	if opt == nil {
+		opt = &DedupOptions{}
+	}
+	switch opt.By {
+	case DedupBySeq, DedupByID, DedupByName:
+	default:
+		return nil, fmt.Errorf("fastx: invalid key of deduplication: %s", opt.By)
+	}
+	d := &Deduplicator{opt: *opt}
+	if opt.BloomExpectedItems > 0 {
+		fpr := opt.BloomFPR
+		if fpr == 0 {
+			fpr = 0.001
+		}
+		if fpr < 0 || fpr >= 1 {
+			return nil, fmt.Errorf("fastx: invalid false positive rate of Bloom filter: %f", fpr)
+		}
+		d.bloom = newBloomFilter(opt.BloomExpectedItems, fpr)
+		d.opt.TrackGroups = false
+	} else {
+		d.seen = make(map[uint64]int, 1024)
+	}
+	return d, nil
+}
+
+// Key returns the hash of the key of a record.
+func (d *Deduplicator) Key(record *Record) uint64 {
+	var s []byte
+	switch d.opt.By {
+	case DedupByID:
+		s = record.ID
+	case DedupByName:
+		s = record.Name
+	default:
+		s = record.Seq.Seq
+	}
+	if d.opt.IgnoreCase {
+		s = bytes.ToUpper(s)
+	}
+	h := wyhash.Hash(s, 1)
+
+	if d.opt.By == DedupBySeq && d.opt.BothStrands {
+		a := record.Seq.Alphabet
+		if a != seq.Protein && a != seq.Unlimit {
+			rc := record.Seq.RevCom().Seq
+			if d.opt.IgnoreCase {
+				rc = bytes.ToUpper(rc)
+			}
+			if h2 := wyhash.Hash(rc, 1); h2 < h {
+				h = h2
+			}
+		}
+	}
+	return h
+}
+
+// IsDuplicate checks and adds a record, and returns true if the key has been seen.
+func (d *Deduplicator) IsDuplicate(record *Record) bool {
+	d.nRecords++
+	h := d.Key(record)
+
+	if d.bloom != nil {
+		if d.bloom.add(h) {
+			d.nDups++
+			return true
+		}
+		return false
+	}
+
+	idx, ok := d.seen[h]
+	if !ok {
+		if d.opt.TrackGroups {
+			d.seen[h] = len(d.groups)
+			d.groups = append(d.groups, &DuplicateGroup{IDs: []string{string(record.ID)}})
+		} else {
+			d.seen[h] = -1
+		}
+		return false
+	}
+	d.nDups++
+	if idx >= 0 {
+		d.groups[idx].IDs = append(d.groups[idx].IDs, string(record.ID))
+	}
+	return true
+}
+
+// Filter reads all records from the reader, and calls fn for unique ones.
+// The reader is not closed.
+func (d *Deduplicator) Filter(reader *Reader, fn func(record *Record) error) error {
+	var record *Record
+	var err error
+	for {
+		record, err = reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if d.IsDuplicate(record) {
+			continue
+		}
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// Records returns the number of records checked.
+func (d *Deduplicator) Records() uint64 { return d.nRecords }
+
+// Duplicates returns the number of duplicate records.
+func (d *Deduplicator) Duplicates() uint64 { return d.nDups }
+
+// Groups returns the duplicate groups with at least two records in the order
+// of first occurrence, which is only available with TrackGroups and not in
+// the bounded-memory mode.
+func (d *Deduplicator) Groups() []*DuplicateGroup {
+	var groups []*DuplicateGroup
+	for _, g := range d.groups {
+		if len(g.IDs) > 1 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// ------------------------------------------------------------------------
+
+// bloomFilter is a Bloom filter of 64-bit hashes.
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    int    // number of hash functions
+}
+
+func newBloomFilter(n uint64, fpr float64) *bloomFilter {
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpr) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+// add adds a hash, and returns true if it might have been added.
+func (b *bloomFilter) add(h uint64) bool {
+	// double hashing
+	h1, h2 := h, wyhash.Hash(nil, h)|1
+	existed := true
+	var i uint64
+	for j := 0; j < b.k; j++ {
+		i = (h1 + uint64(j)*h2) % b.m
+		if b.bits[i>>6]&(1<<(i&63)) == 0 {
+			existed = false
+			b.bits[i>>6] |= 1 << (i & 63)
+		}
+	}
+	return existed
+}
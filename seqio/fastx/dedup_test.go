@@ -0,0 +1,63 @@
+package fastx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const dedupData = ">r1 a\nACGTTT\n>r2 b\nAAACGT\n>r3 c\nacgttt\n>r1 d\nGGGG\n>r5 e\nACGTTT\n"
+
+func TestDeduplicator(t *testing.T) {
+	tests := []struct {
+		opt      DedupOptions
+		expected string
+	}{
+		{DedupOptions{}, "r1 a,r2 b,r3 c,r1 d"},
+		{DedupOptions{IgnoreCase: true}, "r1 a,r2 b,r1 d"},
+		{DedupOptions{BothStrands: true}, "r1 a,r3 c,r1 d"},
+		{DedupOptions{BothStrands: true, IgnoreCase: true}, "r1 a,r1 d"},
+		{DedupOptions{By: DedupByID}, "r1 a,r2 b,r3 c,r5 e"},
+		{DedupOptions{By: DedupByName}, "r1 a,r2 b,r3 c,r1 d,r5 e"},
+		{DedupOptions{BloomExpectedItems: 100}, "r1 a,r2 b,r3 c,r1 d"},
+	}
+	for i, test := range tests {
+		d, err := NewDeduplicator(&test.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names, err := runStage(t, dedupData, d.Filter)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if names != test.expected {
+			t.Errorf("#%d: expected %s, returned %s", i, test.expected, names)
+		}
+		if d.Records() != 5 || d.Records()-d.Duplicates() != uint64(len(strings.Split(names, ","))) {
+			t.Errorf("#%d: counts error: %d, %d", i, d.Records(), d.Duplicates())
+		}
+	}
+
+	d, _ := NewDeduplicator(&DedupOptions{BothStrands: true, TrackGroups: true})
+	runStage(t, dedupData, d.Filter)
+	groups := d.Groups()
+	if len(groups) != 1 || strings.Join(groups[0].IDs, ",") != "r1,r2,r5" {
+		t.Errorf("duplicate groups error: %v", groups)
+	}
+
+	// records are not counted after an error of fn
+	d, _ = NewDeduplicator(nil)
+	runStage(t, dedupData, func(reader *Reader, emit func(*Record) error) error {
+		return d.Filter(reader, func(record *Record) error { return errors.New("stop") })
+	})
+	if d.Records() != 1 {
+		t.Errorf("counts error after an error of fn: %d", d.Records())
+	}
+
+	for _, opt := range []DedupOptions{{By: DedupKey(9)}, {BloomExpectedItems: 10, BloomFPR: 1}} {
+		if _, err := NewDeduplicator(&opt); err == nil {
+			t.Errorf("invalid options should be reported: %+v", opt)
+		}
+	}
+}
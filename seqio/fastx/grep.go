@@ -0,0 +1,406 @@
+package fastx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+// GrepTarget is the part of records to match.
+type GrepTarget int
+
+const (
+	// GrepByID matches record IDs.
+	GrepByID GrepTarget = iota
+	// GrepByName matches full names, i.e., header lines.
+	GrepByName
+	// GrepByDesc matches descriptions, i.e., header lines without IDs.
+	GrepByDesc
+	// GrepBySeq matches sequences.
+	GrepBySeq
+)
+
+// DefaultGrepThreads is the default number of threads of Grepper.Grep.
+var DefaultGrepThreads = runtime.NumCPU()
+
+// DefaultGrepChunkSize is the default number of records in a chunk for Grepper.Grep.
+var DefaultGrepChunkSize = 100
+
+// GrepOptions contains the options of filtering records.
+type GrepOptions struct {
+	By       GrepTarget
+	Patterns []string // a record matching any of them is matched
+
+	// UseRegexp treats patterns as regular expressions. Otherwise, patterns
+	// of IDs, names and descriptions are matched exactly, and sequence
+	// patterns are searched as substrings. Regular expressions of sequences
+	// are only searched on the positive strand without mismatches.
+	UseRegexp  bool
+	IgnoreCase bool
+
+	// Options for sequence patterns
+	Degenerate         bool // patterns contain degenerate bases, see seq.Seq.Degenerate2Regexp
+	MaxMismatches      int  // maximum number of mismatches
+	OnlyPositiveStrand bool // do not search the reverse complement strand
+
+	Invert bool // keep records not matched
+
+	Threads   int // number of threads, 0 for DefaultGrepThreads
+	ChunkSize int // number of records in a chunk, 0 for DefaultGrepChunkSize
+}
+
+// seqPattern is a sequence pattern and its reverse complement.
+type seqPattern struct {
+	p, rc   []byte
+	re, rcR *regexp.Regexp
+}
+
+// Grepper filters records by IDs, names, descriptions or sequences.
+// It's safe for concurrent use.
+type Grepper struct {
+	opt GrepOptions
+
+	set  map[string]struct{}
+	res  []*regexp.Regexp
+	seqs []seqPattern
+
+	baseMatch *[256][256]bool // for sequence patterns with mismatches
+}
+
+// NewGrepper creates a Grepper.
+func NewGrepper(opt *GrepOptions) (*Grepper, error) {
+	if len(opt.Patterns) == 0 {
+		return nil, fmt.Errorf("fastx: no patterns given for filtering")
+	}
+	if opt.By < GrepByID || opt.By > GrepBySeq {
+		return nil, fmt.Errorf("fastx: invalid target of filtering: %d", opt.By)
+	}
+	if opt.MaxMismatches < 0 {
+		return nil, fmt.Errorf("fastx: invalid number of mismatches: %d", opt.MaxMismatches)
+	}
+	if opt.By == GrepBySeq && opt.UseRegexp && (opt.Degenerate || opt.MaxMismatches > 0) {
+		return nil, fmt.Errorf("fastx: regular expressions of sequences can't be used along with degenerate bases or mismatches")
+	}
+
+	g := &Grepper{opt: *opt}
+	if g.opt.Threads <= 0 {
+		g.opt.Threads = DefaultGrepThreads
+	}
+	if g.opt.ChunkSize <= 0 {
+		g.opt.ChunkSize = DefaultGrepChunkSize
+	}
+
+	compile := func(expr string) (*regexp.Regexp, error) {
+		if opt.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("fastx: fail to compile regexp: %s", expr)
+		}
+		return re, nil
+	}
+
+	var err error
+	var re *regexp.Regexp
+	if opt.By != GrepBySeq {
+		if !opt.UseRegexp {
+			g.set = make(map[string]struct{}, len(opt.Patterns))
+		}
+		for _, p := range opt.Patterns {
+			if opt.UseRegexp {
+				if re, err = compile(p); err != nil {
+					return nil, err
+				}
+				g.res = append(g.res, re)
+			} else if opt.IgnoreCase {
+				g.set[strings.ToLower(p)] = struct{}{}
+			} else {
+				g.set[p] = struct{}{}
+			}
+		}
+		return g, nil
+	}
+
+	if opt.MaxMismatches > 0 {
+		g.baseMatch = baseMatchTable(opt.Degenerate, opt.IgnoreCase)
+	}
+	var sp seqPattern
+	for _, p := range opt.Patterns {
+		if p == "" {
+			return nil, fmt.Errorf("fastx: empty sequence pattern")
+		}
+		sp = seqPattern{p: []byte(p)}
+		if !opt.UseRegexp {
+			s, _ := seq.NewSeqWithoutValidation(seq.DNAredundant, []byte(p))
+			sp.rc = s.RevComInplace().Seq
+		}
+
+		if opt.MaxMismatches == 0 {
+			switch {
+			case opt.UseRegexp:
+				sp.re, err = compile(p)
+			case opt.Degenerate:
+				if sp.re, err = compile(degenerate2Regexp(sp.p)); err == nil {
+					sp.rcR, err = compile(degenerate2Regexp(sp.rc))
+				}
+			case opt.IgnoreCase:
+				if sp.re, err = compile(regexp.QuoteMeta(p)); err == nil {
+					sp.rcR, err = compile(regexp.QuoteMeta(string(sp.rc)))
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		g.seqs = append(g.seqs, sp)
+	}
+	return g, nil
+}
+
+// Match returns true if the record is matched, or not matched
+// if GrepOptions.Invert is true.
+func (g *Grepper) Match(record *Record) bool {
+	return g.match(record) != g.opt.Invert
+}
+
+func (g *Grepper) match(record *Record) bool {
+	var target []byte
+	switch g.opt.By {
+	case GrepByID:
+		target = record.ID
+	case GrepByName:
+		target = record.Name
+	case GrepByDesc:
+		target = record.Desc
+	default:
+		bothStrands := !g.opt.OnlyPositiveStrand && record.Seq.Alphabet != seq.Protein
+		for _, sp := range g.seqs {
+			if g.searchSeq(record.Seq.Seq, &sp, bothStrands) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if g.set != nil {
+		var ok bool
+		if g.opt.IgnoreCase {
+			_, ok = g.set[strings.ToLower(string(target))]
+		} else {
+			_, ok = g.set[string(target)]
+		}
+		return ok
+	}
+	for _, re := range g.res {
+		if re.Match(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchSeq searches a sequence pattern in s.
+func (g *Grepper) searchSeq(s []byte, sp *seqPattern, bothStrands bool) bool {
+	if sp.re != nil {
+		return sp.re.Match(s) || (bothStrands && sp.rcR != nil && sp.rcR.Match(s))
+	}
+	if g.opt.MaxMismatches == 0 { // case-sensitive literal patterns
+		return bytes.Contains(s, sp.p) || (bothStrands && bytes.Contains(s, sp.rc))
+	}
+
+	m := g.opt.MaxMismatches
+	return fuzzyIndex(s, sp.p, m, g.baseMatch) >= 0 || (bothStrands && fuzzyIndex(s, sp.rc, m, g.baseMatch) >= 0)
+}
+
+// degenerate2Regexp converts a nucleotide pattern to a regular expression,
+// see seq.Seq.Degenerate2Regexp.
+func degenerate2Regexp(p []byte) string {
+	s, _ := seq.NewSeqWithoutValidation(seq.DNAredundant, p)
+	return s.Degenerate2Regexp()
+}
+
+// baseMatchTable returns the table of whether a base (the second index)
+// is matched by a pattern base (the first index). Degenerate bases follow
+// seq.DegenerateBaseMapNucl, other bases are matched literally.
+func baseMatchTable(degenerate bool, ignoreCase bool) *[256][256]bool {
+	var t [256][256]bool
+	for i := 0; i < 256; i++ {
+		t[i][i] = true
+	}
+	if degenerate {
+		for p, class := range seq.DegenerateBaseMapNucl {
+			t[p][p] = false
+			for _, b := range []byte(strings.Trim(class, "[]")) {
+				t[p][b] = true
+			}
+		}
+	}
+	if !ignoreCase {
+		return &t
+	}
+
+	var t2 [256][256]bool
+	var p2, b2 byte
+	for p := 0; p < 256; p++ {
+		p2 = swapCase(byte(p))
+		for b := 0; b < 256; b++ {
+			b2 = swapCase(byte(b))
+			t2[p][b] = t[p][b] || t[p][b2] || t[p2][b] || t[p2][b2]
+		}
+	}
+	return &t2
+}
+
+func swapCase(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 32
+	}
+	if b >= 'a' && b <= 'z' {
+		return b - 32
+	}
+	return b
+}
+
+// fuzzyIndex returns the index of the first occurrence of the pattern in s
+// with at most maxMismatches mismatches, or -1 if not found.
+func fuzzyIndex(s, pattern []byte, maxMismatches int, match *[256][256]bool) int {
+	var e int
+	for i := 0; i+len(pattern) <= len(s); i++ {
+		e = 0
+		for j, p := range pattern {
+			if !match[p][s[i+j]] {
+				if e++; e > maxMismatches {
+					break
+				}
+			}
+		}
+		if e <= maxMismatches {
+			return i
+		}
+	}
+	return -1
+}
+
+// grepChunk is a chunk of matched records.
+type grepChunk struct {
+	id      uint64
+	records []*Record
+}
+
+// Grep filters records from the reader in parallel, calls fn for matched
+// records in the input order, and returns the number of them. fn could be
+// nil for counting only. Records are cloned ones and could be kept.
+// The reader is not closed, and it's no longer used after returning.
+func (g *Grepper) Grep(reader *Reader, fn func(record *Record) error) (uint64, error) {
+	done := make(chan struct{})
+	ch := make(chan RecordChunk, g.opt.Threads)
+	var wgRead sync.WaitGroup
+	wgRead.Add(1)
+	go func() { // reading stops once done is closed
+		defer wgRead.Done()
+		defer close(ch)
+		var id uint64
+		records := make([]*Record, 0, g.opt.ChunkSize)
+		var record *Record
+		var err error
+		for {
+			if record, err = reader.Read(); err == nil {
+				records = append(records, record.Clone())
+				if len(records) < g.opt.ChunkSize {
+					continue
+				}
+			} else if err == io.EOF {
+				err = nil
+				if len(records) == 0 {
+					return
+				}
+			}
+			select {
+			case ch <- RecordChunk{ID: id, Data: records, Err: err}:
+			case <-done:
+				return
+			}
+			if err != nil || len(records) < g.opt.ChunkSize {
+				return
+			}
+			id++
+			records = make([]*Record, 0, g.opt.ChunkSize)
+		}
+	}()
+	defer func() { // wait for the reading goroutine, which finishes after reading at most one record
+		close(done)
+		wgRead.Wait()
+	}()
+
+	out := make(chan grepChunk, g.opt.Threads)
+	errs := make(chan error, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < g.opt.Threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range ch {
+				if chunk.Err != nil {
+					select {
+					case errs <- chunk.Err:
+					default:
+					}
+				}
+				matched := make([]*Record, 0, len(chunk.Data))
+				for _, record := range chunk.Data {
+					if g.Match(record) {
+						matched = append(matched, record)
+					}
+				}
+				select {
+				case out <- grepChunk{id: chunk.ID, records: matched}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	// output in the order of chunks
+	var n, next uint64
+	buf := make(map[uint64][]*Record, g.opt.Threads)
+	var records []*Record
+	var ok bool
+	for chunk := range out {
+		buf[chunk.id] = chunk.records
+		for {
+			if records, ok = buf[next]; !ok {
+				break
+			}
+			delete(buf, next)
+			next++
+			for _, record := range records {
+				n++
+				if fn == nil {
+					continue
+				}
+				if err := fn(record); err != nil {
+					return n, err
+				}
+			}
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return n, err
+	default:
+	}
+	return n, nil
+}
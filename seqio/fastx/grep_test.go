@@ -0,0 +1,141 @@
+package fastx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGrepper(t *testing.T) {
+	data := ">r1 sample=A\nACGAATTCAA\n>R2 sample=b\nccgaattc\n>r3\nTTTGTGTCCC\n>r4 sample=a\nGGGGTTTTAA\n"
+	cases := []struct {
+		opt      GrepOptions
+		expected string
+	}{
+		{GrepOptions{By: GrepByID, Patterns: []string{"r1", "r2"}}, "r1"},
+		{GrepOptions{By: GrepByID, Patterns: []string{"r1", "r2"}, IgnoreCase: true}, "r1,R2"},
+		{GrepOptions{By: GrepByID, Patterns: []string{"r1", "r2"}, Invert: true}, "R2,r3,r4"},
+		{GrepOptions{By: GrepByDesc, Patterns: []string{`sample=[ab]$`}, UseRegexp: true}, "R2,r4"},
+		{GrepOptions{By: GrepByName, Patterns: []string{`^r\d sample`}, UseRegexp: true, IgnoreCase: true}, "r1,R2,r4"},
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"GAATTC"}}, "r1"},
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"GAATTC"}, IgnoreCase: true}, "r1,R2"},
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"GACACA"}}, "r3"},                         // reverse strand
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"GACACA"}, OnlyPositiveStrand: true}, ""}, // positive strand only
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"GRAYTC"}, Degenerate: true}, "r1"},       // degenerate
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"GRAYTC"}, Degenerate: true, IgnoreCase: true}, "r1,R2"},
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"TTAAAACCCN"}, Degenerate: true}, "r4"}, // degenerate on the reverse strand
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"TGTGACC"}, MaxMismatches: 1}, "r3"},    // mismatch
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"GGNCACA"}, MaxMismatches: 1, Degenerate: true}, "r3"},
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"gRaYtc"}, MaxMismatches: 1, Degenerate: true, IgnoreCase: true}, "r1,R2,r3"},
+		{GrepOptions{By: GrepBySeq, Patterns: []string{"^GG+"}, UseRegexp: true}, "r4"},
+	}
+	for _, c := range cases {
+		g, err := NewGrepper(&c.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n uint64
+		names, err := runStage(t, data, func(reader *Reader, emit func(*Record) error) (err error) {
+			n, err = g.Grep(reader, emit)
+			return err
+		})
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var ids []string
+		for _, name := range strings.Split(names, ",") {
+			if name != "" {
+				ids = append(ids, strings.Fields(name)[0])
+			}
+		}
+		if strings.Join(ids, ",") != c.expected || n != uint64(len(ids)) {
+			t.Errorf("%+v: %s (%d) != %s", c.opt, ids, n, c.expected)
+		}
+	}
+
+	// degenerate bases with and without mismatches are consistent, where
+	// degenerate bases in sequences are not matched by unambiguous ones
+	for _, pattern := range []string{"GAATTN", "GAATTC", "ACGN"} {
+		var results []string
+		for _, m := range []int{0, 1} {
+			g, _ := NewGrepper(&GrepOptions{By: GrepBySeq, Patterns: []string{pattern}, Degenerate: true,
+				MaxMismatches: m, OnlyPositiveStrand: true})
+			names, _ := runStage(t, ">a\nCCGAATTCC\n>b\nCCGAANTCC\n>c\nACGT\n", func(reader *Reader, emit func(*Record) error) error {
+				_, err := g.Grep(reader, emit)
+				return err
+			})
+			results = append(results, names)
+		}
+		if pattern == "GAATTN" && results[0] != "a" {
+			t.Errorf("degenerate pattern error: %s: %s", pattern, results[0])
+		}
+		if pattern == "GAATTC" && (results[0] != "a" || results[1] != "a,b") {
+			t.Errorf("degenerate pattern error: %s: %v", pattern, results)
+		}
+		if pattern == "ACGN" && results[0] != "c" {
+			t.Errorf("degenerate pattern error: %s: %s", pattern, results[0])
+		}
+	}
+
+	// parallel and ordered, and counting only
+	var buf strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&buf, ">s%d\n%s\n", i, []string{"ACGT", "GGCC"}[i%3/2])
+	}
+	g, _ := NewGrepper(&GrepOptions{By: GrepBySeq, Patterns: []string{"ACGT"}, Threads: 4, ChunkSize: 7})
+	names, err := runStage(t, buf.String(), func(reader *Reader, emit func(*Record) error) error {
+		_, err := g.Grep(reader, emit)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := strings.Split(names, ",")
+	if len(ids) != 667 {
+		t.Errorf("parallel filtering error: %d records", len(ids))
+	}
+	for i, id := range ids {
+		if id != fmt.Sprintf("s%d", i/2*3+i%2) {
+			t.Errorf("records not in the input order: %s at %d", id, i)
+			break
+		}
+	}
+	runStage(t, buf.String(), func(reader *Reader, emit func(*Record) error) error {
+		if n, err := g.Grep(reader, nil); err != nil || n != 667 {
+			t.Errorf("counting error: %d, %v", n, err)
+		}
+		return nil
+	})
+
+	// an error of fn is returned without reading the rest of the input
+	errStop := errors.New("stop")
+	runStage(t, buf.String(), func(reader *Reader, emit func(*Record) error) error {
+		var calls int
+		n, err := g.Grep(reader, func(record *Record) error {
+			if calls++; calls == 5 {
+				return errStop
+			}
+			return nil
+		})
+		if n != 5 || !errors.Is(err, errStop) {
+			t.Errorf("error of fn should be returned: %d, %v", n, err)
+		}
+		if _, err = reader.Read(); err != nil {
+			t.Errorf("the rest of the input should not be read: %v", err)
+		}
+		return nil
+	})
+
+	for _, opt := range []GrepOptions{
+		{},
+		{By: GrepBySeq, Patterns: []string{"A+"}, UseRegexp: true, MaxMismatches: 1},
+		{By: GrepBySeq, Patterns: []string{""}},
+		{By: GrepByID, Patterns: []string{"("}, UseRegexp: true},
+	} {
+		if _, err := NewGrepper(&opt); err == nil {
+			t.Errorf("invalid options should be reported: %+v", opt)
+		}
+	}
+}
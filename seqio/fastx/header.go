@@ -0,0 +1,437 @@
+package fastx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidHeader means the header can't be parsed by a HeaderParser,
+// or the metadata can't be formatted to a header.
+var ErrInvalidHeader = errors.New("fastx: invalid header")
+
+// HeaderParser parses headers of records into key/value metadata, and formats
+// metadata back to headers. Headers are the full names of records, i.e., Record.Name.
+type HeaderParser interface {
+	Parse(head []byte) (*HeaderMetadata, error)
+	Format(m *HeaderMetadata) ([]byte, error)
+}
+
+// HeaderField is a key/value field of a header.
+type HeaderField struct {
+	Key   string
+	Value string
+}
+
+// HeaderMetadata is the metadata parsed from a header, where the order of
+// fields is kept.
+type HeaderMetadata struct {
+	Parser HeaderParser // for formatting back to a header
+	Fields []HeaderField
+
+	trailingBar bool // for NCBI headers like gi|123|ref|NC_000913.3|
+}
+
+// Get returns the value of a key.
+func (m *HeaderMetadata) Get(key string) (string, bool) {
+	for _, f := range m.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Int returns the value of a key as an integer.
+func (m *HeaderMetadata) Int(key string) (int, error) {
+	v, ok := m.Get(key)
+	if !ok {
+		return 0, fmt.Errorf("fastx: key not found: %s", key)
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("fastx: invalid integer of %s: %s", key, v)
+	}
+	return i, nil
+}
+
+// Float returns the value of a key as a float number.
+func (m *HeaderMetadata) Float(key string) (float64, error) {
+	v, ok := m.Get(key)
+	if !ok {
+		return 0, fmt.Errorf("fastx: key not found: %s", key)
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("fastx: invalid float number of %s: %s", key, v)
+	}
+	return f, nil
+}
+
+// Set sets the value of a key, new keys are appended.
+func (m *HeaderMetadata) Set(key, value string) {
+	for i, f := range m.Fields {
+		if f.Key == key {
+			m.Fields[i].Value = value
+			return
+		}
+	}
+	m.Fields = append(m.Fields, HeaderField{key, value})
+}
+
+// Delete deletes a key.
+func (m *HeaderMetadata) Delete(key string) {
+	for i, f := range m.Fields {
+		if f.Key == key {
+			m.Fields = append(m.Fields[:i], m.Fields[i+1:]...)
+			return
+		}
+	}
+}
+
+// Map returns the fields in a map.
+func (m *HeaderMetadata) Map() map[string]string {
+	fields := make(map[string]string, len(m.Fields))
+	for _, f := range m.Fields {
+		fields[f.Key] = f.Value
+	}
+	return fields
+}
+
+// Format formats the metadata back to a header with the parser.
+func (m *HeaderMetadata) Format() ([]byte, error) {
+	if m.Parser == nil {
+		return nil, fmt.Errorf("%w: no parser for formatting", ErrInvalidHeader)
+	}
+	return m.Parser.Format(m)
+}
+
+// ParseHeader parses the header (Name) of the record with the parser.
+func (record *Record) ParseHeader(p HeaderParser) (*HeaderMetadata, error) {
+	m, err := p.Parse(record.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", record.ID, err)
+	}
+	return m, nil
+}
+
+// SetHeader formats the metadata and updates the header (Name) of the record,
+// ID and Desc are split by the first whitespace like the DefaultIDRegexp.
+func (record *Record) SetHeader(m *HeaderMetadata) error {
+	head, err := m.Format()
+	if err != nil {
+		return fmt.Errorf("%s: %w", record.ID, err)
+	}
+	record.Name = head
+	record.ID, record.Desc = splitHead(head)
+	return nil
+}
+
+// splitHead splits a header into the ID and description by the first whitespace.
+func splitHead(head []byte) ([]byte, []byte) {
+	if i := bytes.IndexAny(head, " \t"); i >= 0 {
+		return head[:i], bytes.TrimLeft(head[i+1:], " \t")
+	}
+	return head, emptyByteSlice
+}
+
+func splitHeadString(head []byte) (string, string) {
+	id, desc := splitHead(head)
+	return string(id), string(desc)
+}
+
+// formatFields writes fields of keys joined by sep, all keys are required.
+func formatFields(buf *bytes.Buffer, m *HeaderMetadata, keys []string, sep byte) error {
+	for i, key := range keys {
+		v, ok := m.Get(key)
+		if !ok {
+			return fmt.Errorf("%w: %s not found", ErrInvalidHeader, key)
+		}
+		if i > 0 {
+			buf.WriteByte(sep)
+		}
+		buf.WriteString(v)
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------
+
+// CasavaHeaderParser parses Illumina Casava 1.8+ headers, e.g.,
+//
+//	EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG
+//
+// into the keys of instrument, run, flowcell, lane, tile, x, y, (umi),
+// read, filtered, control, index and (desc), where desc is the text after
+// the Casava fields, e.g., comments added by other tools.
+type CasavaHeaderParser struct{}
+
+var casavaIDKeys = []string{"instrument", "run", "flowcell", "lane", "tile", "x", "y"}
+var casavaDescKeys = []string{"read", "filtered", "control", "index"}
+
+// Parse parses a header.
+func (p CasavaHeaderParser) Parse(head []byte) (*HeaderMetadata, error) {
+	id, desc := splitHeadString(head)
+	items := strings.Split(id, ":")
+	if len(items) != 7 && len(items) != 8 {
+		return nil, fmt.Errorf("%w: Casava 1.8 ID expected: %s", ErrInvalidHeader, id)
+	}
+	m := &HeaderMetadata{Parser: p, Fields: make([]HeaderField, 0, 12)}
+	for i, key := range casavaIDKeys {
+		m.Fields = append(m.Fields, HeaderField{key, items[i]})
+	}
+	if len(items) == 8 {
+		m.Fields = append(m.Fields, HeaderField{"umi", items[7]})
+	}
+
+	var rest string
+	if i := strings.IndexAny(desc, " \t"); i >= 0 {
+		desc, rest = desc[:i], strings.TrimLeft(desc[i+1:], " \t")
+	}
+	items = strings.SplitN(desc, ":", 4)
+	if len(items) != 4 {
+		return nil, fmt.Errorf("%w: Casava 1.8 description expected: %s", ErrInvalidHeader, desc)
+	}
+	for i, key := range casavaDescKeys {
+		m.Fields = append(m.Fields, HeaderField{key, items[i]})
+	}
+	if rest != "" {
+		m.Fields = append(m.Fields, HeaderField{"desc", rest})
+	}
+
+	for _, key := range []string{"run", "lane", "tile", "x", "y", "read", "control"} {
+		if _, err := m.Int(key); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHeader, err)
+		}
+	}
+	return m, nil
+}
+
+// Format formats metadata to a header.
+func (p CasavaHeaderParser) Format(m *HeaderMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := formatFields(&buf, m, casavaIDKeys, ':'); err != nil {
+		return nil, err
+	}
+	if umi, ok := m.Get("umi"); ok {
+		buf.WriteByte(':')
+		buf.WriteString(umi)
+	}
+	buf.WriteByte(' ')
+	if err := formatFields(&buf, m, casavaDescKeys, ':'); err != nil {
+		return nil, err
+	}
+	if desc, ok := m.Get("desc"); ok && desc != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(desc)
+	}
+	return buf.Bytes(), nil
+}
+
+// ------------------------------------------------------------------------
+
+// NCBIHeaderParser parses NCBI headers with database identifiers, e.g.,
+//
+//	gi|49175990|ref|NC_000913.2| Escherichia coli K-12
+//	gi|21434723|gb|AAM53449.1|AF516291_1 description
+//
+// where the databases are keys and identifiers are values, an unpaired
+// identifier at the end is the key "name", and the description is "desc".
+type NCBIHeaderParser struct{}
+
+// Parse parses a header.
+func (p NCBIHeaderParser) Parse(head []byte) (*HeaderMetadata, error) {
+	id, desc := splitHeadString(head)
+	items := strings.Split(id, "|")
+	if len(items) < 2 {
+		return nil, fmt.Errorf("%w: NCBI identifiers expected: %s", ErrInvalidHeader, id)
+	}
+	m := &HeaderMetadata{Parser: p, Fields: make([]HeaderField, 0, len(items)/2+2)}
+	var i int
+	for ; i+1 < len(items); i += 2 {
+		if items[i] == "" {
+			return nil, fmt.Errorf("%w: empty database: %s", ErrInvalidHeader, id)
+		}
+		m.Fields = append(m.Fields, HeaderField{items[i], items[i+1]})
+	}
+	if i < len(items) {
+		if items[i] == "" {
+			m.trailingBar = true
+		} else {
+			m.Fields = append(m.Fields, HeaderField{"name", items[i]})
+		}
+	}
+	if desc != "" {
+		m.Fields = append(m.Fields, HeaderField{"desc", desc})
+	}
+	return m, nil
+}
+
+// Format formats metadata to a header.
+func (p NCBIHeaderParser) Format(m *HeaderMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+	var name, desc string
+	var hasName bool
+	var n int
+	for _, f := range m.Fields {
+		switch f.Key {
+		case "name":
+			name, hasName = f.Value, true
+		case "desc":
+			desc = f.Value
+		default:
+			if n > 0 {
+				buf.WriteByte('|')
+			}
+			buf.WriteString(f.Key)
+			buf.WriteByte('|')
+			buf.WriteString(f.Value)
+			n++
+		}
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: no NCBI identifiers", ErrInvalidHeader)
+	}
+	if hasName {
+		buf.WriteByte('|')
+		buf.WriteString(name)
+	} else if m.trailingBar {
+		buf.WriteByte('|')
+	}
+	if desc != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(desc)
+	}
+	return buf.Bytes(), nil
+}
+
+// ------------------------------------------------------------------------
+
+// UniProtHeaderParser parses UniProtKB headers, e.g.,
+//
+//	sp|P69905|HBA_HUMAN Hemoglobin subunit alpha OS=Homo sapiens OX=9606 GN=HBA1 PE=1 SV=2
+//
+// into the keys of db, accession, entry, protein, and the ones of
+// fields like OS, OX, GN, PE and SV.
+type UniProtHeaderParser struct{}
+
+var reUniProtField = regexp.MustCompile(`\s([A-Z]{2})=`)
+
+// Parse parses a header.
+func (p UniProtHeaderParser) Parse(head []byte) (*HeaderMetadata, error) {
+	id, desc := splitHeadString(head)
+	items := strings.Split(id, "|")
+	if len(items) != 3 {
+		return nil, fmt.Errorf("%w: UniProtKB ID expected: %s", ErrInvalidHeader, id)
+	}
+	m := &HeaderMetadata{Parser: p, Fields: make([]HeaderField, 0, 9)}
+	m.Fields = append(m.Fields,
+		HeaderField{"db", items[0]},
+		HeaderField{"accession", items[1]},
+		HeaderField{"entry", items[2]})
+
+	desc = " " + desc
+	locs := reUniProtField.FindAllStringSubmatchIndex(desc, -1)
+	end := len(desc)
+	if len(locs) > 0 {
+		end = locs[0][0]
+	}
+	m.Fields = append(m.Fields, HeaderField{"protein", strings.TrimSpace(desc[:end])})
+	for i, loc := range locs {
+		end = len(desc)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		m.Fields = append(m.Fields, HeaderField{desc[loc[2]:loc[3]], strings.TrimSpace(desc[loc[1]:end])})
+	}
+	return m, nil
+}
+
+// Format formats metadata to a header.
+func (p UniProtHeaderParser) Format(m *HeaderMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := formatFields(&buf, m, []string{"db", "accession", "entry"}, '|'); err != nil {
+		return nil, err
+	}
+	if protein, ok := m.Get("protein"); ok && protein != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(protein)
+	}
+	for _, f := range m.Fields {
+		switch f.Key {
+		case "db", "accession", "entry", "protein":
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		buf.WriteString(f.Value)
+	}
+	return buf.Bytes(), nil
+}
+
+// ------------------------------------------------------------------------
+
+// KeyValueHeaderParser parses descriptions of key=value fields, e.g.,
+//
+//	read1 runid=abc ch=345 barcode=barcode01
+//
+// where the ID is the key "id". Fields are separated by whitespace, or Sep if given.
+type KeyValueHeaderParser struct {
+	Sep string // separator of fields, "" for whitespace
+}
+
+// Parse parses a header.
+func (p KeyValueHeaderParser) Parse(head []byte) (*HeaderMetadata, error) {
+	id, desc := splitHeadString(head)
+	m := &HeaderMetadata{Parser: p, Fields: []HeaderField{{"id", id}}}
+	var items []string
+	if p.Sep == "" {
+		items = strings.Fields(desc)
+	} else {
+		items = strings.Split(desc, p.Sep)
+	}
+	var i int
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if i = strings.IndexByte(item, '='); i <= 0 {
+			return nil, fmt.Errorf("%w: key=value expected: %s", ErrInvalidHeader, item)
+		}
+		m.Fields = append(m.Fields, HeaderField{item[:i], item[i+1:]})
+	}
+	return m, nil
+}
+
+// Format formats metadata to a header.
+func (p KeyValueHeaderParser) Format(m *HeaderMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := formatFields(&buf, m, []string{"id"}, ' '); err != nil {
+		return nil, err
+	}
+	sep := p.Sep
+	if sep == "" {
+		sep = " "
+	}
+	var n int
+	for _, f := range m.Fields {
+		if f.Key == "id" {
+			continue
+		}
+		if n == 0 {
+			buf.WriteByte(' ')
+		} else {
+			buf.WriteString(sep)
+		}
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		buf.WriteString(f.Value)
+		n++
+	}
+	return buf.Bytes(), nil
+}
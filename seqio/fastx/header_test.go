@@ -0,0 +1,93 @@
+package fastx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHeaderParsers(t *testing.T) {
+	tests := []struct {
+		parser HeaderParser
+		head   string
+		key    string
+		value  string
+	}{
+		{CasavaHeaderParser{}, "EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG", "flowcell", "FC706VJ"},
+		{CasavaHeaderParser{}, "EAS139:136:FC706VJ:2:2104:15343:197393:ACGTAC 2:N:0:ATCACG+GTACGT", "index", "ATCACG+GTACGT"},
+		{CasavaHeaderParser{}, "EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG length=150 sample=s1", "desc", "length=150 sample=s1"},
+		{NCBIHeaderParser{}, "gi|49175990|ref|NC_000913.2| Escherichia coli K-12", "ref", "NC_000913.2"},
+		{NCBIHeaderParser{}, "gi|21434723|gb|AAM53449.1|AF516291_1 description", "name", "AF516291_1"},
+		{NCBIHeaderParser{}, "lcl|seq1", "lcl", "seq1"},
+		{UniProtHeaderParser{}, "sp|P69905|HBA_HUMAN Hemoglobin subunit alpha OS=Homo sapiens OX=9606 GN=HBA1 PE=1 SV=2", "OS", "Homo sapiens"},
+		{KeyValueHeaderParser{}, "read1 runid=abc ch=345 barcode=barcode01", "ch", "345"},
+		{KeyValueHeaderParser{Sep: ";"}, "read1 a=1;b=x y;c=", "b", "x y"},
+	}
+	for i, test := range tests {
+		m, err := test.parser.Parse([]byte(test.head))
+		if err != nil {
+			t.Errorf("#%d: %s", i, err)
+			continue
+		}
+		if v, ok := m.Get(test.key); !ok || v != test.value {
+			t.Errorf("#%d: value of %s error: %s", i, test.key, v)
+		}
+		head, err := m.Format()
+		if err != nil {
+			t.Errorf("#%d: %s", i, err)
+			continue
+		}
+		if string(head) != test.head {
+			t.Errorf("#%d: formatting error: %s", i, head)
+		}
+	}
+
+	m, _ := UniProtHeaderParser{}.Parse([]byte(tests[6].head))
+	if v, _ := m.Get("protein"); v != "Hemoglobin subunit alpha" {
+		t.Errorf("protein name error: %s", v)
+	}
+	if v, err := m.Int("OX"); err != nil || v != 9606 {
+		t.Errorf("typed value error: %d, %v", v, err)
+	}
+
+	for _, test := range []struct {
+		parser HeaderParser
+		head   string
+	}{
+		{CasavaHeaderParser{}, "read1 1:N:0:ATCACG"},
+		{CasavaHeaderParser{}, "EAS139:136:FC706VJ:x:2104:15343:197393 1:Y:18:ATCACG"},
+		{NCBIHeaderParser{}, "NC_000913.2"},
+		{UniProtHeaderParser{}, "P69905 Hemoglobin"},
+		{KeyValueHeaderParser{}, "read1 runid=abc 345"},
+	} {
+		if _, err := test.parser.Parse([]byte(test.head)); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("invalid header should be reported: %s", test.head)
+		}
+	}
+}
+
+func TestRecordSetHeader(t *testing.T) {
+	record, _ := NewRecordWithoutValidation(nil, []byte("EAS139:136:FC706VJ:2:2104:15343:197393"),
+		[]byte("EAS139:136:FC706VJ:2:2104:15343:197393 1:Y:18:ATCACG"),
+		[]byte("1:Y:18:ATCACG"), []byte("ACGT"))
+	m, err := record.ParseHeader(CasavaHeaderParser{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	m.Set("filtered", "N")
+	m.Set("umi", "GGCC")
+	if err = record.SetHeader(m); err != nil {
+		t.Error(err)
+		return
+	}
+	if string(record.Name) != "EAS139:136:FC706VJ:2:2104:15343:197393:GGCC 1:N:18:ATCACG" ||
+		string(record.ID) != "EAS139:136:FC706VJ:2:2104:15343:197393:GGCC" ||
+		string(record.Desc) != "1:N:18:ATCACG" {
+		t.Errorf("setting header error: %s, %s, %s", record.Name, record.ID, record.Desc)
+	}
+
+	m.Delete("index")
+	if err = record.SetHeader(m); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("missing keys should be reported")
+	}
+}
@@ -0,0 +1,425 @@
+package fastx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shenwei356/bio/seq"
+	"github.com/shenwei356/xopen"
+)
+
+// DefaultIndexExt is the extension of index files of IndexedFile.
+var DefaultIndexExt = ".fxi"
+
+// ErrCompressedFile means compressed files can't be indexed for random access.
+var ErrCompressedFile = errors.New("fastx: compressed files can't be indexed")
+
+// ErrDuplicatedID means there are records with the same ID in an indexed file.
+var ErrDuplicatedID = errors.New("fastx: duplicated ID")
+
+// ErrRecordNotFound means the ID does not exist in the index.
+var ErrRecordNotFound = errors.New("fastx: record not found")
+
+// ErrEmptyID means a record with an empty ID is found when creating an index.
+var ErrEmptyID = errors.New("fastx: empty ID")
+
+// ErrInvalidIndex means the index file is invalid or outdated.
+var ErrInvalidIndex = errors.New("fastx: invalid index file")
+
+// IndexEntry is the location of a record in a file.
+type IndexEntry struct {
+	Offset int64 // offset of the header line
+	Length int64 // length of the record in bytes
+}
+
+// Index maps record IDs to byte offsets in a plain FASTA/Q file, where
+// lines of any widths and multi-line FASTQ records are allowed.
+type Index struct {
+	IDRegexp string // "" for the DefaultIDRegexp
+	Size     int64  // size of the file
+	ModTime  int64  // modification time of the file in nanoseconds
+
+	IDs     []string // in the order of the file
+	Entries map[string]IndexEntry
+}
+
+// CreateIndex scans a plain FASTA/Q file and creates the index, IDs are
+// parsed with the idRegexp like NewReader. Records with empty IDs are not
+// allowed, as they can't be retrieved.
+func CreateIndex(file string, idRegexp string) (*Index, error) {
+	re, err := compileIDRegexp(idRegexp)
+	if err != nil {
+		return nil, err
+	}
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+	idx := &Index{
+		IDRegexp: idRegexp,
+		Size:     info.Size(),
+		ModTime:  info.ModTime().UnixNano(),
+		Entries:  make(map[string]IndexEntry, 1024),
+	}
+
+	s := &lineScanner{r: bufio.NewReaderSize(fh, 65536)}
+	if compressed, err := isCompressed(s.r); err != nil {
+		return nil, err
+	} else if compressed {
+		return nil, ErrCompressedFile
+	}
+
+	add := func(head []byte, start, end int64) error {
+		var id string
+		if idRegexp == "" { // the same as Reader with the DefaultIDRegexp
+			id, _ = splitHeadString(head)
+		} else {
+			id = string(ParseHeadID(re, head))
+		}
+		if id == "" {
+			return fmt.Errorf("%w: record at offset %d", ErrEmptyID, start)
+		}
+		if _, ok := idx.Entries[id]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicatedID, id)
+		}
+		idx.IDs = append(idx.IDs, id)
+		idx.Entries[id] = IndexEntry{Offset: start, Length: end - start}
+		return nil
+	}
+
+	var start, recStart int64
+	var first byte
+	var n, seqLen, qualLen int
+	var line, head []byte
+	var fastq, inRecord bool
+	var formatChecked bool
+	for {
+		start, first, n, line, err = s.next(true)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		if !formatChecked {
+			switch first {
+			case '>':
+			case '@':
+				fastq = true
+			default:
+				return nil, ErrNotFASTXFormat
+			}
+			formatChecked = true
+		}
+
+		if !fastq {
+			if first == '>' {
+				if inRecord {
+					if err = add(head, recStart, start); err != nil {
+						return nil, err
+					}
+				}
+				head, recStart, inRecord = append(head[:0], line[1:]...), start, true
+			}
+			continue
+		}
+
+		// FASTQ
+		if first != '@' {
+			return nil, fmt.Errorf("%w: header line expected at offset %d", ErrBadFASTQFormat, start)
+		}
+		head, recStart = append(head[:0], line[1:]...), start
+		seqLen, qualLen = 0, 0
+		for { // sequence lines
+			_, first, n, _, err = s.next(false)
+			if err != nil {
+				return nil, fmt.Errorf("%w: incomplete record: %s", ErrBadFASTQFormat, head)
+			}
+			if n > 0 && first == '+' {
+				break
+			}
+			seqLen += n
+		}
+		for qualLen < seqLen { // quality lines
+			_, _, n, _, err = s.next(false)
+			if err != nil {
+				return nil, fmt.Errorf("%w: incomplete record: %s", ErrBadFASTQFormat, head)
+			}
+			qualLen += n
+		}
+		if qualLen != seqLen {
+			return nil, fmt.Errorf("%w: %s", ErrUnequalSeqAndQual, head)
+		}
+		if err = add(head, recStart, s.offset); err != nil {
+			return nil, err
+		}
+	}
+	if !fastq && inRecord {
+		if err = add(head, recStart, s.offset); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// isCompressed checks the magic numbers of gzip, xz, zstd and bzip2.
+func isCompressed(r *bufio.Reader) (bool, error) {
+	for _, f := range []func(*bufio.Reader) (bool, error){xopen.IsGzip, xopen.IsXz, xopen.IsZst, xopen.IsBzip2} {
+		ok, err := f(r)
+		if err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, fmt.Errorf("fastx: %s", err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func compileIDRegexp(idRegexp string) (*regexp.Regexp, error) {
+	if idRegexp == "" {
+		return regexp.MustCompile(DefaultIDRegexp), nil
+	}
+	if !reCheckIDregexpStr.MatchString(idRegexp) {
+		return nil, fmt.Errorf(`fastx: regular expression must contain "(" and ")" to capture matched ID. default: %s`, DefaultIDRegexp)
+	}
+	re, err := regexp.Compile(idRegexp)
+	if err != nil {
+		return nil, fmt.Errorf("fastx: fail to compile regexp: %s", idRegexp)
+	}
+	return re, nil
+}
+
+// lineScanner reads lines with offsets.
+type lineScanner struct {
+	r      *bufio.Reader
+	offset int64 // offset of the next line
+
+	lastByte byte
+}
+
+// next returns the offset, the first byte and the length of the next line
+// without line endings, and the content if keep is true.
+func (s *lineScanner) next(keep bool) (start int64, first byte, n int, line []byte, err error) {
+	start = s.offset
+	var chunk []byte
+	var prev byte // the byte before the last one
+	for {
+		chunk, err = s.r.ReadSlice('\n')
+		if len(chunk) > 0 {
+			if s.offset == start {
+				first = chunk[0]
+			}
+			if len(chunk) > 1 {
+				prev = chunk[len(chunk)-2]
+			} else if n > 0 {
+				prev = s.lastByte
+			}
+			s.lastByte = chunk[len(chunk)-1]
+			s.offset += int64(len(chunk))
+			n += len(chunk)
+			if keep {
+				line = append(line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && n > 0 {
+			err = nil
+		}
+		break
+	}
+	if err != nil {
+		return start, 0, 0, nil, err
+	}
+	if keep {
+		line = dropCR(dropLF(line))
+		return start, first, len(line), line, nil
+	}
+	if s.lastByte == '\n' {
+		n--
+		if n > 0 && prev == '\r' {
+			n--
+		}
+	} else if s.lastByte == '\r' {
+		n--
+	}
+	return start, first, n, nil, nil
+}
+
+// Write writes the index in a tab-delimited format, with a header line of
+// the file size, modification time and the ID regular expression,
+// followed by lines of IDs, offsets and lengths.
+func (idx *Index) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#fastx-index\t%d\t%d\t%s\n", idx.Size, idx.ModTime, idx.IDRegexp)
+	var e IndexEntry
+	for _, id := range idx.IDs {
+		e = idx.Entries[id]
+		fmt.Fprintf(bw, "%s\t%d\t%d\n", id, e.Offset, e.Length)
+	}
+	return bw.Flush()
+}
+
+// ReadIndex reads an index file written by Index.Write.
+func ReadIndex(fileIndex string) (*Index, error) {
+	fh, err := xopen.Ropen(fileIndex)
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+	defer fh.Close()
+
+	var idx *Index
+	var items []string
+	var offset, length int64
+	var line string
+	for {
+		line, err = fh.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			if idx == nil { // header line
+				items = strings.SplitN(line, "\t", 4)
+				if len(items) != 4 || items[0] != "#fastx-index" {
+					return nil, fmt.Errorf("%w: %s", ErrInvalidIndex, fileIndex)
+				}
+				idx = &Index{IDRegexp: items[3], Entries: make(map[string]IndexEntry, 1024)}
+				idx.Size, err = strconv.ParseInt(items[1], 10, 64)
+				if err == nil {
+					idx.ModTime, err = strconv.ParseInt(items[2], 10, 64)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s: %s", ErrInvalidIndex, fileIndex, line)
+				}
+			} else {
+				items = strings.Split(line, "\t")
+				if len(items) != 3 {
+					return nil, fmt.Errorf("%w: %s: %s", ErrInvalidIndex, fileIndex, line)
+				}
+				offset, err = strconv.ParseInt(items[1], 10, 64)
+				if err == nil {
+					length, err = strconv.ParseInt(items[2], 10, 64)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s: %s", ErrInvalidIndex, fileIndex, line)
+				}
+				idx.IDs = append(idx.IDs, items[0])
+				idx.Entries[items[0]] = IndexEntry{Offset: offset, Length: length}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("fastx: %s", err)
+		}
+	}
+	if idx == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIndex, fileIndex)
+	}
+	return idx, nil
+}
+
+// ------------------------------------------------------------------------
+
+// IndexedFile provides random access of records by IDs in a plain FASTA/Q file.
+type IndexedFile struct {
+	Index *Index
+
+	fh       *os.File
+	alphabet *seq.Alphabet
+}
+
+// OpenIndexed opens a plain FASTA/Q file for random access, the index is
+// read from the sidecar file (file + DefaultIndexExt), or created and saved
+// if it does not exist or is outdated, i.e., the file size or modification
+// time changed, or the idRegexp differs.
+//
+// Parameters are the same as NewReader: the alphabet could be nil for guessing
+// it from each record, and idRegexp "" is for the DefaultIDRegexp.
+func OpenIndexed(alphabet *seq.Alphabet, file string, idRegexp string) (*IndexedFile, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+	info, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+
+	fileIndex := file + DefaultIndexExt
+	idx, err := ReadIndex(fileIndex)
+	if err != nil || idx.Size != info.Size() || idx.ModTime != info.ModTime().UnixNano() ||
+		idx.IDRegexp != idRegexp {
+		if idx, err = CreateIndex(file, idRegexp); err != nil {
+			fh.Close()
+			return nil, err
+		}
+		outfh, err := xopen.Wopen(fileIndex)
+		if err != nil {
+			fh.Close()
+			return nil, fmt.Errorf("fastx: %s", err)
+		}
+		err = idx.Write(outfh)
+		if e := outfh.Close(); err == nil {
+			err = e
+		}
+		if err != nil {
+			fh.Close()
+			return nil, fmt.Errorf("fastx: %s", err)
+		}
+	}
+	return &IndexedFile{Index: idx, fh: fh, alphabet: alphabet}, nil
+}
+
+// IDs returns IDs of all records in the order of the file.
+func (f *IndexedFile) IDs() []string {
+	return f.Index.IDs
+}
+
+// Get returns the record of an ID, ErrRecordNotFound is returned if not found.
+func (f *IndexedFile) Get(id string) (*Record, error) {
+	e, ok := f.Index.Entries[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+	buf := make([]byte, e.Length)
+	if _, err := f.fh.ReadAt(buf, e.Offset); err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+
+	reader, err := NewReaderFromIO(f.alphabet, bytes.NewReader(buf), f.Index.IDRegexp)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	record, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s: %s", id, err)
+	}
+	return record.Clone(), nil
+}
+
+// Close closes the file.
+func (f *IndexedFile) Close() error {
+	return f.fh.Close()
+}
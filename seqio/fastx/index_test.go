@@ -0,0 +1,96 @@
+package fastx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func TestIndexedFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		data string
+		seqs map[string]string
+		qual map[string]string
+	}{
+		{ // ragged FASTA with CRLF and blank lines
+			data: ">s1 desc\r\nACGTA\r\nCG\r\n\r\n>s2\nA\nCCCGGGTT\nA\n>s3\nNNN",
+			seqs: map[string]string{"s1": "ACGTACG", "s2": "ACCCGGGTTA", "s3": "NNN"},
+		},
+		{ // multi-line FASTQ, where quality lines start with '@' or '+'
+			data: "@r1 x\nACGT\nAC\n+r1 x\n@@II\n+I\n@r2\nAC\n+\n@I\n@r3\nA\n+\n+\n",
+			seqs: map[string]string{"r1": "ACGTAC", "r2": "AC", "r3": "A"},
+			qual: map[string]string{"r1": "@@II+I", "r2": "@I", "r3": "+"},
+		},
+	}
+	for i, test := range tests {
+		file := filepath.Join(dir, "seqs"+string(rune('0'+i)))
+		if err := os.WriteFile(file, []byte(test.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		for round := 0; round < 2; round++ { // creating and reading the index
+			f, err := OpenIndexed(seq.DNAredundant, file, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(f.IDs()) != len(test.seqs) {
+				t.Errorf("number of IDs error: %v", f.IDs())
+			}
+			for _, id := range []string{"s3", "s1", "s2", "r3", "r1", "r2"} {
+				if _, ok := test.seqs[id]; !ok {
+					continue
+				}
+				record, err := f.Get(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(record.ID) != id || string(record.Seq.Seq) != test.seqs[id] ||
+					string(record.Seq.Qual) != test.qual[id] {
+					t.Errorf("record error: %s", record.Format(0))
+				}
+			}
+			if _, err = f.Get("x"); !errors.Is(err, ErrRecordNotFound) {
+				t.Errorf("missing ID should be reported: %v", err)
+			}
+			f.Close()
+		}
+	}
+
+	// outdated index
+	file := filepath.Join(dir, "seqs0")
+	os.WriteFile(file, []byte(">a\nAC\n>b\nG\n"), 0644)
+	os.Chtimes(file, time.Now(), time.Now().Add(time.Hour))
+	f, err := OpenIndexed(nil, file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if strings.Join(f.IDs(), ",") != "a,b" {
+		t.Errorf("outdated index should be rebuilt: %v", f.IDs())
+	}
+
+	os.WriteFile(file, []byte(">a\nAC\n>a\nG\n"), 0644)
+	if _, err = CreateIndex(file, ""); !errors.Is(err, ErrDuplicatedID) {
+		t.Errorf("duplicated IDs should be reported: %v", err)
+	}
+	for _, data := range []string{">\nAC\n>b\nG\n", ">a\nAC\n>\nG\n", ">a\nAC\n> desc\nG\n", "@\nA\n+\nI\n"} {
+		os.WriteFile(file, []byte(data), 0644)
+		if _, err = CreateIndex(file, ""); !errors.Is(err, ErrEmptyID) {
+			t.Errorf("empty ID should be reported: %q, %v", data, err)
+		}
+	}
+	os.WriteFile(file, []byte("@a\nACG\n+\nII\n"), 0644)
+	if _, err = CreateIndex(file, ""); !errors.Is(err, ErrBadFASTQFormat) {
+		t.Errorf("truncated record should be reported: %v", err)
+	}
+	os.WriteFile(file, []byte{0x1f, 0x8b, 8, 0}, 0644)
+	if _, err = CreateIndex(file, ""); !errors.Is(err, ErrCompressedFile) {
+		t.Errorf("compressed file should be reported: %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package fastx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/shenwei356/bio/seq"
+	"github.com/shenwei356/bio/util"
+)
+
+// LongReadStats is a streaming collector of statistics of long reads
+// (Nanopore/PacBio), including length-weighted N50/NX, per-read mean error
+// probabilities and qscores, and quality distributions across deciles of
+// read length, i.e., the first 10% bases of all reads, the second 10%, ...
+//
+// LongReadStats is not safe for concurrent use.
+type LongReadStats struct {
+	encoding seq.QualityEncoding
+	phred    [256]int
+
+	lengths *util.LengthStats
+
+	qualReads uint64  // number of reads with qualities
+	qualBases uint64  // number of bases with qualities
+	errors    float64 // sum of error probabilities of all bases
+
+	qscores [nQual]uint64 // histogram of per-read qscores
+	deciles [10][nQual]uint64
+}
+
+// NewLongReadStats creates a LongReadStats for records of a quality encoding,
+// Unknown is treated as Sanger (Phred+33).
+func NewLongReadStats(encoding seq.QualityEncoding) *LongReadStats {
+	if encoding == seq.Unknown {
+		encoding = seq.Sanger
+	}
+	return &LongReadStats{
+		encoding: encoding,
+		phred:    phredTable(encoding),
+		lengths:  util.NewLengthStats(),
+	}
+}
+
+// Add adds a record, and returns the mean error probability of the read,
+// which is 0 for records without qualities.
+func (s *LongReadStats) Add(record *Record) float64 {
+	l := len(record.Seq.Seq)
+	s.lengths.Add(uint64(l))
+
+	qual := record.Seq.Qual
+	if l == 0 || len(qual) != l {
+		return 0
+	}
+
+	var q int
+	var sum float64
+	for i, b := range qual {
+		q = s.phred[b]
+		sum += seq.QUAL_MAP[q]
+		s.deciles[i*10/l][q]++
+	}
+	s.qualReads++
+	s.qualBases += uint64(l)
+	s.errors += sum
+
+	p := sum / float64(l)
+	s.qscores[qscoreBin(p)]++
+	return p
+}
+
+// qscoreBin returns the rounded qscore of a mean error probability.
+func qscoreBin(p float64) int {
+	if p <= 0 {
+		return nQual - 1
+	}
+	q := int(math.Round(-10 * math.Log10(p)))
+	if q >= nQual {
+		q = nQual - 1
+	}
+	return q
+}
+
+// Reads returns the number of reads.
+func (s *LongReadStats) Reads() uint64 { return s.lengths.Count() }
+
+// Bases returns the number of bases.
+func (s *LongReadStats) Bases() uint64 { return s.lengths.Sum() }
+
+// Lengths returns the statistics of read lengths.
+func (s *LongReadStats) Lengths() *util.LengthStats { return s.lengths }
+
+// N50 returns the read-length-weighted N50.
+func (s *LongReadStats) N50() uint64 { return s.lengths.N50() }
+
+// NX returns the read-length-weighted NX, where X is in the range of [0, 100].
+func (s *LongReadStats) NX(x float64) uint64 { return s.lengths.NX(x) }
+
+// MeanErrorProb returns the mean error probability of all bases with qualities.
+func (s *LongReadStats) MeanErrorProb() float64 {
+	if s.qualBases == 0 {
+		return 0
+	}
+	return s.errors / float64(s.qualBases)
+}
+
+// MeanQScore returns the Phred-scaled mean error probability of all bases.
+func (s *LongReadStats) MeanQScore() float64 {
+	if s.qualBases == 0 {
+		return 0
+	}
+	return -10 * math.Log10(s.MeanErrorProb())
+}
+
+// ReadQScores returns the histogram of rounded per-read qscores, see seq.Seq.MeanQScore.
+func (s *LongReadStats) ReadQScores() []ValueCount {
+	var h []ValueCount
+	for q, c := range s.qscores {
+		if c > 0 {
+			h = append(h, ValueCount{q, c})
+		}
+	}
+	return h
+}
+
+// ReadQScoreDistribution returns the distribution of rounded per-read qscores.
+func (s *LongReadStats) ReadQScoreDistribution() QualityDistribution {
+	return newQualityDistribution(&s.qscores)
+}
+
+// Decile returns the quality distribution of the ith (0-9) decile of read length.
+func (s *LongReadStats) Decile(i int) QualityDistribution {
+	if i < 0 || i >= len(s.deciles) {
+		return QualityDistribution{}
+	}
+	return newQualityDistribution(&s.deciles[i])
+}
+
+// ------------------------------------------------------------------------
+
+// ErrInvalidNanoporeDesc means the description of a record has no Nanopore
+// metadata or has invalid values.
+var ErrInvalidNanoporeDesc = errors.New("fastx: invalid Nanopore description")
+
+// NanoporeMetadata is the metadata in the header of Nanopore reads, e.g.,
+//
+//	@id runid=abc read=12 ch=345 start_time=2021-01-01T00:00:00Z flow_cell_id=FAK00000
+type NanoporeMetadata struct {
+	RunID      string
+	Read       int // read number
+	Channel    int
+	StartTime  time.Time
+	FlowCellID string
+	SampleID   string
+	Barcode    string
+
+	Fields map[string]string // all key=value fields
+}
+
+// ParseNanoporeDesc parses Nanopore metadata from the description of a record,
+// i.e., whitespace-separated key=value fields, where runid is required.
+func ParseNanoporeDesc(desc []byte) (*NanoporeMetadata, error) {
+	m := &NanoporeMetadata{Fields: make(map[string]string, 8)}
+	var i int
+	var key, value string
+	for _, field := range bytes.Fields(desc) {
+		if i = bytes.IndexByte(field, '='); i <= 0 {
+			continue
+		}
+		key, value = string(field[:i]), string(field[i+1:])
+		m.Fields[key] = value
+	}
+
+	var ok bool
+	var err error
+	if m.RunID, ok = m.Fields["runid"]; !ok {
+		return nil, fmt.Errorf("%w: runid not found", ErrInvalidNanoporeDesc)
+	}
+	if value, ok = m.Fields["read"]; ok {
+		if m.Read, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%w: read=%s", ErrInvalidNanoporeDesc, value)
+		}
+	}
+	if value, ok = m.Fields["ch"]; ok {
+		if m.Channel, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%w: ch=%s", ErrInvalidNanoporeDesc, value)
+		}
+	}
+	if value, ok = m.Fields["start_time"]; ok {
+		if m.StartTime, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("%w: start_time=%s", ErrInvalidNanoporeDesc, value)
+		}
+	}
+	m.FlowCellID = m.Fields["flow_cell_id"]
+	if m.SampleID, ok = m.Fields["sample_id"]; !ok {
+		m.SampleID = m.Fields["sampleid"]
+	}
+	m.Barcode = m.Fields["barcode"]
+	return m, nil
+}
+
+// NanoporeMetadata parses Nanopore metadata from the description of the record,
+// see ParseNanoporeDesc.
+func (record *Record) NanoporeMetadata() (*NanoporeMetadata, error) {
+	m, err := ParseNanoporeDesc(record.Desc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", record.ID, err)
+	}
+	return m, nil
+}
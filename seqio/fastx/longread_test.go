@@ -0,0 +1,91 @@
+package fastx
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func TestLongReadStats(t *testing.T) {
+	reads := [][2]string{
+		{strings.Repeat("A", 10), strings.Repeat("+", 10)}, // Q10
+		{strings.Repeat("C", 20), strings.Repeat("5", 20)}, // Q20
+		{strings.Repeat("G", 30), strings.Repeat("I", 15) + strings.Repeat("+", 15)},
+	}
+	s := NewLongReadStats(seq.Sanger)
+	for i, r := range reads {
+		record, _ := NewRecordWithQual(seq.DNA, []byte("r"), []byte("r"), nil, []byte(r[0]), []byte(r[1]))
+		p := s.Add(record)
+		if i == 2 && math.Abs(p-0.05005) > 1e-9 {
+			t.Errorf("mean error probability of read error: %f", p)
+		}
+	}
+
+	if s.Reads() != 3 || s.Bases() != 60 {
+		t.Errorf("reads/bases error: %d, %d", s.Reads(), s.Bases())
+	}
+	if s.N50() != 30 || s.NX(90) != 10 {
+		t.Errorf("N50/N90 error: %d, %d", s.N50(), s.NX(90))
+	}
+	if math.Abs(s.MeanErrorProb()-2.7015/60) > 1e-9 {
+		t.Errorf("mean error probability error: %f", s.MeanErrorProb())
+	}
+
+	qscores := s.ReadQScores()
+	if len(qscores) != 3 || qscores[0].Value != 10 || qscores[1].Value != 13 || qscores[2].Value != 20 {
+		t.Errorf("read qscores error: %v", qscores)
+	}
+	if d := s.ReadQScoreDistribution(); d.Median != 13 {
+		t.Errorf("median read qscore error: %d", d.Median)
+	}
+
+	if d := s.Decile(0); d.Median != 20 || d.P90 != 40 {
+		t.Errorf("quality distribution of the first decile error: %+v", d)
+	}
+	if d := s.Decile(9); d.Median != 10 || d.P90 != 20 {
+		t.Errorf("quality distribution of the last decile error: %+v", d)
+	}
+}
+
+func TestNanoporeMetadata(t *testing.T) {
+	reader, err := NewReaderFromIO(seq.DNA, strings.NewReader(
+		"@r1 runid=abc sampleid=s1 read=12 ch=345 start_time=2021-01-02T03:04:05Z flow_cell_id=FAK1 barcode=barcode01\nACGT\n+\nIIII\n"+
+			"@r2 runid=abc ch=x\nACGT\n+\nIIII\n"), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer reader.Close()
+
+	record, err := reader.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	m, err := record.NanoporeMetadata()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if m.RunID != "abc" || m.Read != 12 || m.Channel != 345 || m.FlowCellID != "FAK1" ||
+		m.SampleID != "s1" || m.Barcode != "barcode01" ||
+		!m.StartTime.Equal(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("parsing Nanopore metadata error: %+v", m)
+	}
+
+	record, err = reader.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = record.NanoporeMetadata(); !errors.Is(err, ErrInvalidNanoporeDesc) {
+		t.Errorf("invalid channel should be reported")
+	}
+	if _, err = ParseNanoporeDesc([]byte("length=100")); !errors.Is(err, ErrInvalidNanoporeDesc) {
+		t.Errorf("missing runid should be reported")
+	}
+}
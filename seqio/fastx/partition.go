@@ -0,0 +1,285 @@
+package fastx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/shenwei356/xopen"
+	"github.com/zeebo/wyhash"
+)
+
+// PartitionMode is the way of partitioning records.
+type PartitionMode int
+
+const (
+	// PartitionByCount puts every PartitionOptions.Records records into a part.
+	PartitionByCount PartitionMode = iota
+	// PartitionByBases puts records into a part until the cumulative bases
+	// reach PartitionOptions.Bases. Records longer than it are in their own parts.
+	PartitionByBases
+	// PartitionByHash puts records into PartitionOptions.Parts parts by hashes
+	// of IDs, so paired-end files are partitioned consistently.
+	PartitionByHash
+	// PartitionByRegexp puts records into parts named by the capture groups of
+	// PartitionOptions.Regexp matched in record names, joined by "_".
+	// Records not matched are in the part PartitionUnmatched.
+	PartitionByRegexp
+)
+
+// PartitionUnmatched is the part of records not matched in PartitionByRegexp.
+// Captured values equal to it are treated as invalid part names.
+const PartitionUnmatched = "unmatched"
+
+// ErrInvalidPartName means all the captures of PartitionByRegexp are empty,
+// or the part name only consists of dots, or is PartitionUnmatched.
+var ErrInvalidPartName = errors.New("fastx: invalid part name")
+
+// PartitionOptions contains the options of partitioning records.
+type PartitionOptions struct {
+	Mode PartitionMode
+
+	Records int            // for PartitionByCount
+	Bases   int            // for PartitionByBases
+	Parts   int            // for PartitionByHash
+	Regexp  *regexp.Regexp // for PartitionByRegexp
+
+	// TrimPairSuffix removes the suffixes "/1" and "/2" of IDs before hashing.
+	TrimPairSuffix bool
+
+	// Output files are named OutPrefix + part + OutSuffix, where parts are
+	// "part_001", "part_002", ... or the ones from regular expressions.
+	// Files are compressed according to the extension in OutSuffix, e.g., ".fq.gz".
+	OutPrefix string
+	OutSuffix string
+
+	MaxOpenFiles int // maximum number of files opened at the same time, 0 for 256
+	LineWidth    int // line width of FASTA sequences, 0 for no wrapping
+}
+
+// PartitionFile is an output file of Partitioner.
+type PartitionFile struct {
+	Part    string
+	File    string
+	Records uint64
+	Bases   uint64
+}
+
+// partitionWriter is an output file.
+type partitionWriter struct {
+	*PartitionFile
+	outfh    *xopen.Writer
+	lastUsed uint64
+}
+
+// Partitioner splits records into multiple files. Files are closed when the
+// number of open files exceeds the limit, and reopened in append mode later,
+// which is valid for gzip, xz, zstd and bzip2 formats.
+//
+// Partitioner is not safe for concurrent use.
+type Partitioner struct {
+	opt PartitionOptions
+
+	writers map[string]*partitionWriter
+	files   []*PartitionFile
+	nOpen   int
+	tick    uint64
+
+	current *partitionWriter // for PartitionByCount and PartitionByBases
+}
+
+// NewPartitioner creates a Partitioner. Nil opt is treated as empty options,
+// for which an error is returned as the number of records per part is not given.
+func NewPartitioner(opt *PartitionOptions) (*Partitioner, error) {
+	if opt == nil {
+		opt = &PartitionOptions{}
+	}
+	switch opt.Mode {
+	case PartitionByCount:
+		if opt.Records <= 0 {
+			return nil, fmt.Errorf("fastx: invalid number of records per part: %d", opt.Records)
+		}
+	case PartitionByBases:
+		if opt.Bases <= 0 {
+			return nil, fmt.Errorf("fastx: invalid number of bases per part: %d", opt.Bases)
+		}
+	case PartitionByHash:
+		if opt.Parts <= 0 {
+			return nil, fmt.Errorf("fastx: invalid number of parts: %d", opt.Parts)
+		}
+	case PartitionByRegexp:
+		if opt.Regexp == nil || opt.Regexp.NumSubexp() == 0 {
+			return nil, fmt.Errorf("fastx: regular expression with capture groups needed for partitioning")
+		}
+	default:
+		return nil, fmt.Errorf("fastx: invalid partition mode: %d", opt.Mode)
+	}
+	p := &Partitioner{opt: *opt, writers: make(map[string]*partitionWriter, 8)}
+	if p.opt.MaxOpenFiles <= 0 {
+		p.opt.MaxOpenFiles = 256
+	}
+	return p, nil
+}
+
+func partName(i int) string {
+	return fmt.Sprintf("part_%03d", i)
+}
+
+// part returns the part of a record.
+func (p *Partitioner) part(record *Record) (string, error) {
+	switch p.opt.Mode {
+	case PartitionByHash:
+		id := record.ID
+		if p.opt.TrimPairSuffix && len(id) > 2 && id[len(id)-2] == '/' &&
+			(id[len(id)-1] == '1' || id[len(id)-1] == '2') {
+			id = id[:len(id)-2]
+		}
+		return partName(int(wyhash.Hash(id, 1)%uint64(p.opt.Parts)) + 1), nil
+	case PartitionByRegexp:
+		found := p.opt.Regexp.FindSubmatch(record.Name)
+		if found == nil {
+			return PartitionUnmatched, nil
+		}
+		part := string(bytes.Join(found[1:], []byte("_")))
+		part = strings.NewReplacer("/", "_", "\\", "_").Replace(part)
+		if len(part) == len(found)-2 || // all captures are empty
+			strings.Trim(part, ".") == "" || part == PartitionUnmatched {
+			return "", fmt.Errorf("%w: %s: %q", ErrInvalidPartName, record.ID, part)
+		}
+		return part, nil
+	}
+
+	// sequential modes
+	c := p.current
+	switch {
+	case c == nil:
+	case p.opt.Mode == PartitionByCount && c.Records >= uint64(p.opt.Records):
+	case p.opt.Mode == PartitionByBases && c.Records > 0 &&
+		c.Bases+uint64(len(record.Seq.Seq)) > uint64(p.opt.Bases):
+	default:
+		return c.Part, nil
+	}
+	return partName(len(p.files) + 1), nil
+}
+
+// writer returns the opened writer of a part.
+func (p *Partitioner) writer(part string) (*partitionWriter, error) {
+	p.tick++
+	w, ok := p.writers[part]
+	if ok && w.outfh != nil {
+		w.lastUsed = p.tick
+		return w, nil
+	}
+
+	if p.opt.Mode == PartitionByCount || p.opt.Mode == PartitionByBases {
+		if p.current != nil {
+			if err := p.closeWriter(p.current); err != nil {
+				return nil, err
+			}
+		}
+	} else if p.nOpen >= p.opt.MaxOpenFiles { // close the least recently used one
+		var lru *partitionWriter
+		for _, w2 := range p.writers {
+			if w2.outfh != nil && (lru == nil || w2.lastUsed < lru.lastUsed) {
+				lru = w2
+			}
+		}
+		if err := p.closeWriter(lru); err != nil {
+			return nil, err
+		}
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !ok {
+		w = &partitionWriter{PartitionFile: &PartitionFile{
+			Part: part,
+			File: p.opt.OutPrefix + part + p.opt.OutSuffix,
+		}}
+		p.writers[part] = w
+		p.files = append(p.files, w.PartitionFile)
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	outfh, err := xopen.WopenFile(w.File, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+	w.outfh = outfh
+	w.lastUsed = p.tick
+	p.nOpen++
+	p.current = w
+	return w, nil
+}
+
+func (p *Partitioner) closeWriter(w *partitionWriter) error {
+	if w.outfh == nil {
+		return nil
+	}
+	err := w.outfh.Close()
+	w.outfh = nil
+	p.nOpen--
+	if err != nil {
+		return fmt.Errorf("fastx: %s: %s", w.File, err)
+	}
+	return nil
+}
+
+// Write writes a record to the file of its part.
+func (p *Partitioner) Write(record *Record) error {
+	part, err := p.part(record)
+	if err != nil {
+		return err
+	}
+	w, err := p.writer(part)
+	if err != nil {
+		return err
+	}
+	record.FormatToWriter(w.outfh, p.opt.LineWidth)
+	w.Records++
+	w.Bases += uint64(len(record.Seq.Seq))
+	return nil
+}
+
+// Close closes all files.
+func (p *Partitioner) Close() error {
+	var err error
+	for _, w := range p.writers {
+		if e := p.closeWriter(w); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Files returns the output files in the order of creation.
+func (p *Partitioner) Files() []*PartitionFile {
+	return p.files
+}
+
+// PartitionReader partitions all records from the reader, and returns the
+// output files. The reader is not closed.
+func PartitionReader(reader *Reader, opt *PartitionOptions) ([]*PartitionFile, error) {
+	p, err := NewPartitioner(opt)
+	if err != nil {
+		return nil, err
+	}
+	var record *Record
+	for {
+		record, err = reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			p.Close()
+			return p.Files(), err
+		}
+		if err = p.Write(record); err != nil {
+			p.Close()
+			return p.Files(), err
+		}
+	}
+	return p.Files(), p.Close()
+}
@@ -0,0 +1,131 @@
+package fastx
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func partition(t *testing.T, data string, opt *PartitionOptions) ([]*PartitionFile, error) {
+	var files []*PartitionFile
+	_, err := runStage(t, data, func(reader *Reader, emit func(*Record) error) (err error) {
+		files, err = PartitionReader(reader, opt)
+		return err
+	})
+	return files, err
+}
+
+func readIDs(t *testing.T, file string) []string {
+	reader, err := NewReader(seq.DNA, file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var ids []string
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		ids = append(ids, string(record.ID))
+	}
+	return ids
+}
+
+func TestPartitioner(t *testing.T) {
+	dir := t.TempDir()
+	data := ">r1/1\nACGTAC\n>r2/1\nAC\n>r3/1\nACGT\n>r4/1\nACG\n>r5/1\nA\n"
+
+	files, _ := partition(t, data, &PartitionOptions{Mode: PartitionByCount, Records: 2,
+		OutPrefix: filepath.Join(dir, "count."), OutSuffix: ".fa.gz"})
+	if len(files) != 3 || files[0].Records != 2 || files[2].Records != 1 {
+		t.Errorf("partitioning by count error: %v", files)
+	}
+	if ids := readIDs(t, files[1].File); strings.Join(ids, ",") != "r3/1,r4/1" {
+		t.Errorf("partitioning by count error: %v", ids)
+	}
+
+	files, _ = partition(t, data, &PartitionOptions{Mode: PartitionByBases, Bases: 6,
+		OutPrefix: filepath.Join(dir, "bases."), OutSuffix: ".fa"})
+	if len(files) != 3 || files[0].Bases != 6 || files[1].Bases != 6 || files[2].Bases != 4 {
+		t.Errorf("partitioning by bases error: %v", files)
+	}
+
+	// paired-end files with limited open files
+	parts := make([]map[string]string, 2)
+	for i, suffix := range []string{"_1.fa.zst", "_2.fa.zst"} {
+		d := data
+		if i == 1 {
+			d = strings.ReplaceAll(data, "/1", "/2")
+		}
+		files, _ = partition(t, d, &PartitionOptions{Mode: PartitionByHash, Parts: 3, TrimPairSuffix: true,
+			MaxOpenFiles: 1, OutPrefix: filepath.Join(dir, "hash."), OutSuffix: suffix})
+		parts[i] = make(map[string]string)
+		var n int
+		for _, f := range files {
+			ids := readIDs(t, f.File)
+			if len(ids) != int(f.Records) {
+				t.Errorf("records of %s error: %d != %d", f.File, len(ids), f.Records)
+			}
+			for _, id := range ids {
+				parts[i][id[:len(id)-2]] = f.Part
+				n++
+			}
+		}
+		if n != 5 {
+			t.Errorf("partitioning by hash error: %d records", n)
+		}
+	}
+	for id, part := range parts[0] {
+		if parts[1][id] != part {
+			t.Errorf("inconsistent parts of paired-end reads: %s", id)
+		}
+	}
+
+	data = ">s1_r1\nA\n>s2_r1\nC\n>s1_r2\nG\n>x\nT\n>s2_r2\nA\n"
+	files, _ = partition(t, data, &PartitionOptions{Mode: PartitionByRegexp, Regexp: regexp.MustCompile(`^(s\d+)_`),
+		MaxOpenFiles: 1, OutPrefix: filepath.Join(dir, "re."), OutSuffix: ".fa.gz"})
+	if len(files) != 3 || files[0].Part != "s1" || files[2].Part != "unmatched" {
+		t.Errorf("partitioning by regexp error: %v", files)
+		return
+	}
+	if ids := readIDs(t, files[1].File); strings.Join(ids, ",") != "s2_r1,s2_r2" {
+		t.Errorf("partitioning by regexp error: %v", ids)
+	}
+
+	// invalid part names
+	for _, data := range []string{">_r1\nA\n", ">.._r1\nA\n", ">unmatched_r1\nA\n"} {
+		_, err := partition(t, data, &PartitionOptions{Mode: PartitionByRegexp, Regexp: regexp.MustCompile(`^(.*)_`),
+			OutPrefix: filepath.Join(dir, "bad."), OutSuffix: ".fa"})
+		if !errors.Is(err, ErrInvalidPartName) {
+			t.Errorf("invalid part name should be reported: %s, %v", data, err)
+		}
+	}
+	data = ">x_r1\nA\n"
+	_, err := partition(t, data, &PartitionOptions{Mode: PartitionByRegexp, Regexp: regexp.MustCompile(`^(s?)(s?)x`),
+		OutPrefix: filepath.Join(dir, "bad."), OutSuffix: ".fa"})
+	if !errors.Is(err, ErrInvalidPartName) {
+		t.Errorf("invalid part name should be reported: %v", err)
+	}
+
+	// errors of output files
+	if _, err = partition(t, data, &PartitionOptions{Mode: PartitionByCount, Records: 1,
+		OutPrefix: filepath.Join(files[0].File, "x.")}); err == nil { // the parent is a file
+		t.Errorf("error of creating files should be reported")
+	}
+
+	if _, err = NewPartitioner(&PartitionOptions{Mode: PartitionByHash}); err == nil {
+		t.Errorf("invalid options should be reported")
+	}
+	if _, err = NewPartitioner(nil); err == nil {
+		t.Errorf("nil options should be reported")
+	}
+}
@@ -0,0 +1,451 @@
+package fastx
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+// QCMaxPositions is the maximum number of positions (cycles) of per-position
+// statistics, bases after it are not counted in per-position statistics.
+var QCMaxPositions = 1000
+
+// QCMaxTrackedSeqs is the maximum number of distinct sequences tracked for
+// duplication and overrepresented sequences, like FastQC.
+var QCMaxTrackedSeqs = 100000
+
+// QCTrackedSeqLen is the length of prefixes of sequences tracked for
+// duplication and overrepresented sequences, like FastQC
+// (sequences longer than 75 bp are truncated to 50 bp).
+var QCTrackedSeqLen = 50
+
+// ErrQCEncodingMismatch means two QCStats of different quality encodings are merged.
+var ErrQCEncodingMismatch = errors.New("fastx: unmatched quality encodings of QC stats")
+
+// nQual is the number of Phred quality values could be encoded in ASCII, i.e., 0-93.
+const nQual = 94
+
+// QCStats is a streaming collector of FastQC-like statistics of FASTA/Q records,
+// including per-position quality distributions and base compositions,
+// histograms of per-read mean qualities, GC contents and lengths, N content,
+// duplication rate and overrepresented sequences.
+//
+// QCStats is not safe for concurrent use, please use one QCStats for each
+// goroutine and merge them with Merge.
+type QCStats struct {
+	encoding seq.QualityEncoding
+	phred    [256]int
+
+	reads  uint64
+	bases  uint64
+	nBases uint64
+	gc     uint64
+
+	lengths   map[int]uint64
+	posQuals  [][nQual]uint64
+	posBases  [][5]uint64 // A, C, G, T, N and others
+	meanQuals [nQual]uint64
+	gcHist    [101]uint64
+
+	tracked    map[string]uint64
+	nTracked   uint64 // number of reads of tracked sequences
+	nUntracked uint64
+}
+
+// NewQCStats creates a QCStats for records of a quality encoding,
+// Unknown is treated as Sanger (Phred+33).
+func NewQCStats(encoding seq.QualityEncoding) *QCStats {
+	if encoding == seq.Unknown {
+		encoding = seq.Sanger
+	}
+	return &QCStats{
+		encoding: encoding,
+		phred:    phredTable(encoding),
+		lengths:  make(map[int]uint64, 256),
+		tracked:  make(map[string]uint64, 1024),
+	}
+}
+
+// phredTable maps quality letters to Phred values in the range of [0, nQual),
+// where invalid ones are mapped to 0.
+func phredTable(encoding seq.QualityEncoding) [256]int {
+	var phred [256]int
+	var qv []int
+	var err error
+	for i := range phred {
+		qv, err = encoding.PhredValues([]byte{byte(i)})
+		switch {
+		case err != nil: // invalid quality
+			phred[i] = 0
+		case qv[0] >= nQual:
+			phred[i] = nQual - 1
+		default:
+			phred[i] = qv[0]
+		}
+	}
+	return phred
+}
+
+// base2idx maps bases to indexes of per-position base compositions.
+var base2idx [256]uint8
+
+func init() {
+	for i := range base2idx {
+		base2idx[i] = 4
+	}
+	for i, b := range []byte("ACGT") {
+		base2idx[b] = uint8(i)
+		base2idx[b+32] = uint8(i)
+	}
+	base2idx['U'], base2idx['u'] = 3, 3
+}
+
+// Add adds a record.
+func (s *QCStats) Add(record *Record) {
+	sequence := record.Seq.Seq
+	qual := record.Seq.Qual
+	if len(qual) != len(sequence) {
+		qual = nil
+	}
+	l := len(sequence)
+
+	s.reads++
+	s.bases += uint64(l)
+	s.lengths[l]++
+
+	for i := len(s.posBases); i < l && i < QCMaxPositions; i++ {
+		s.posBases = append(s.posBases, [5]uint64{})
+		s.posQuals = append(s.posQuals, [nQual]uint64{})
+	}
+
+	var gc, n int
+	var idx uint8
+	for i, b := range sequence {
+		idx = base2idx[b]
+		switch idx {
+		case 1, 2:
+			gc++
+		case 4:
+			n++
+		}
+		if i < QCMaxPositions {
+			s.posBases[i][idx]++
+		}
+	}
+	s.gc += uint64(gc)
+	s.nBases += uint64(n)
+	if l > n {
+		s.gcHist[int(math.Round(float64(gc)*100/float64(l-n)))]++
+	}
+
+	if len(qual) > 0 {
+		var q, sum int
+		for i, b := range qual {
+			q = s.phred[b]
+			sum += q
+			if i < QCMaxPositions {
+				s.posQuals[i][q]++
+			}
+		}
+		s.meanQuals[int(math.Round(float64(sum)/float64(l)))]++
+	}
+
+	// duplication
+	key := sequence
+	if l > 75 && len(key) > QCTrackedSeqLen {
+		key = key[:QCTrackedSeqLen]
+	}
+	if _, ok := s.tracked[string(key)]; ok || len(s.tracked) < QCMaxTrackedSeqs {
+		s.tracked[string(key)]++
+		s.nTracked++
+	} else {
+		s.nUntracked++
+	}
+}
+
+// Merge merges another QCStats into this one.
+// Tracked sequences are merged until the limit QCMaxTrackedSeqs is reached.
+func (s *QCStats) Merge(other *QCStats) error {
+	if s.encoding != other.encoding {
+		return fmt.Errorf("%w: %s, %s", ErrQCEncodingMismatch, s.encoding, other.encoding)
+	}
+	s.reads += other.reads
+	s.bases += other.bases
+	s.nBases += other.nBases
+	s.gc += other.gc
+	for l, c := range other.lengths {
+		s.lengths[l] += c
+	}
+	for i := len(s.posBases); i < len(other.posBases); i++ {
+		s.posBases = append(s.posBases, [5]uint64{})
+		s.posQuals = append(s.posQuals, [nQual]uint64{})
+	}
+	for i := range other.posBases {
+		for j, c := range other.posBases[i] {
+			s.posBases[i][j] += c
+		}
+		for j, c := range other.posQuals[i] {
+			s.posQuals[i][j] += c
+		}
+	}
+	for i, c := range other.meanQuals {
+		s.meanQuals[i] += c
+	}
+	for i, c := range other.gcHist {
+		s.gcHist[i] += c
+	}
+	for key, c := range other.tracked {
+		if _, ok := s.tracked[key]; ok || len(s.tracked) < QCMaxTrackedSeqs {
+			s.tracked[key] += c
+			s.nTracked += c
+		} else {
+			s.nUntracked += c
+		}
+	}
+	s.nUntracked += other.nUntracked
+	return nil
+}
+
+// Reads returns the number of reads.
+func (s *QCStats) Reads() uint64 { return s.reads }
+
+// Bases returns the number of bases.
+func (s *QCStats) Bases() uint64 { return s.bases }
+
+// GCContent returns the GC content, N and other letters are not counted.
+func (s *QCStats) GCContent() float64 {
+	if s.bases == s.nBases {
+		return 0
+	}
+	return float64(s.gc) / float64(s.bases-s.nBases)
+}
+
+// NContent returns the fraction of N and other letters (not A, C, G, T/U).
+func (s *QCStats) NContent() float64 {
+	if s.bases == 0 {
+		return 0
+	}
+	return float64(s.nBases) / float64(s.bases)
+}
+
+// DuplicationRate returns the estimated fraction of duplicated reads,
+// i.e., 1 - distinct/total of the tracked sequences.
+func (s *QCStats) DuplicationRate() float64 {
+	if s.nTracked == 0 {
+		return 0
+	}
+	return 1 - float64(len(s.tracked))/float64(s.nTracked)
+}
+
+// OverrepresentedSeq is an overrepresented sequence.
+type OverrepresentedSeq struct {
+	Seq      string  `json:"seq"`
+	Count    uint64  `json:"count"`
+	Fraction float64 `json:"fraction"`
+}
+
+// Overrepresented returns the tracked sequences accounting for >= minFraction
+// of all reads, in descending order of counts. FastQC uses 0.001.
+func (s *QCStats) Overrepresented(minFraction float64) []OverrepresentedSeq {
+	seqs := make([]OverrepresentedSeq, 0, 8)
+	if s.reads == 0 {
+		return seqs
+	}
+	var f float64
+	for key, c := range s.tracked {
+		f = float64(c) / float64(s.reads)
+		if f >= minFraction && c > 1 {
+			seqs = append(seqs, OverrepresentedSeq{Seq: key, Count: c, Fraction: f})
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool {
+		if seqs[i].Count == seqs[j].Count {
+			return seqs[i].Seq < seqs[j].Seq
+		}
+		return seqs[i].Count > seqs[j].Count
+	})
+	return seqs
+}
+
+// QualityDistribution is the summary of a distribution of quality values.
+type QualityDistribution struct {
+	Mean   float64 `json:"mean"`
+	P10    int     `json:"p10"`
+	Q1     int     `json:"q1"`
+	Median int     `json:"median"`
+	Q3     int     `json:"q3"`
+	P90    int     `json:"p90"`
+}
+
+// newQualityDistribution summarizes a histogram of quality values.
+func newQualityDistribution(hist *[nQual]uint64) QualityDistribution {
+	var d QualityDistribution
+	var total, sum uint64
+	for q, c := range hist {
+		total += c
+		sum += uint64(q) * c
+	}
+	if total == 0 {
+		return d
+	}
+	d.Mean = float64(sum) / float64(total)
+	d.P10 = quantile(hist, total, 0.1)
+	d.Q1 = quantile(hist, total, 0.25)
+	d.Median = quantile(hist, total, 0.5)
+	d.Q3 = quantile(hist, total, 0.75)
+	d.P90 = quantile(hist, total, 0.9)
+	return d
+}
+
+// PositionStats is the statistics of a position (cycle).
+type PositionStats struct {
+	Position int `json:"pos"` // 1-based
+
+	QualityDistribution
+
+	// base composition
+	A uint64 `json:"A"`
+	C uint64 `json:"C"`
+	G uint64 `json:"G"`
+	T uint64 `json:"T"`
+	N uint64 `json:"N"`
+}
+
+// quantile returns the quantile of a histogram of quality values.
+func quantile(hist *[nQual]uint64, total uint64, p float64) int {
+	target := uint64(math.Ceil(p * float64(total)))
+	if target == 0 {
+		target = 1
+	}
+	var acc uint64
+	for q, c := range hist {
+		acc += c
+		if acc >= target {
+			return q
+		}
+	}
+	return 0
+}
+
+// Position returns the statistics of a 0-based position.
+func (s *QCStats) Position(i int) PositionStats {
+	p := PositionStats{Position: i + 1}
+	if i < 0 || i >= len(s.posBases) {
+		return p
+	}
+	b := &s.posBases[i]
+	p.A, p.C, p.G, p.T, p.N = b[0], b[1], b[2], b[3], b[4]
+
+	p.QualityDistribution = newQualityDistribution(&s.posQuals[i])
+	return p
+}
+
+// ValueCount is the count of a value in a histogram.
+type ValueCount struct {
+	Value int    `json:"value"`
+	Count uint64 `json:"count"`
+}
+
+// QCReport is the report of QCStats, which could be serialized to JSON.
+type QCReport struct {
+	Encoding        string  `json:"encoding"`
+	Reads           uint64  `json:"reads"`
+	Bases           uint64  `json:"bases"`
+	GCContent       float64 `json:"gc_content"`
+	NContent        float64 `json:"n_content"`
+	DuplicationRate float64 `json:"duplication_rate"`
+
+	PerPosition     []PositionStats      `json:"per_position"`
+	MeanQualities   []ValueCount         `json:"mean_qualities"`
+	GCContents      []ValueCount         `json:"gc_contents"`
+	Lengths         []ValueCount         `json:"lengths"`
+	Overrepresented []OverrepresentedSeq `json:"overrepresented"`
+}
+
+// Report returns the report, where overrepresented sequences
+// account for >= 0.1% of all reads.
+func (s *QCStats) Report() *QCReport {
+	r := &QCReport{
+		Encoding:        s.encoding.String(),
+		Reads:           s.reads,
+		Bases:           s.bases,
+		GCContent:       s.GCContent(),
+		NContent:        s.NContent(),
+		DuplicationRate: s.DuplicationRate(),
+		PerPosition:     make([]PositionStats, len(s.posBases)),
+		Overrepresented: s.Overrepresented(0.001),
+	}
+	for i := range s.posBases {
+		r.PerPosition[i] = s.Position(i)
+	}
+	for q, c := range s.meanQuals {
+		if c > 0 {
+			r.MeanQualities = append(r.MeanQualities, ValueCount{q, c})
+		}
+	}
+	for gc, c := range s.gcHist {
+		if c > 0 {
+			r.GCContents = append(r.GCContents, ValueCount{gc, c})
+		}
+	}
+	for l, c := range s.lengths {
+		r.Lengths = append(r.Lengths, ValueCount{l, c})
+	}
+	sort.Slice(r.Lengths, func(i, j int) bool { return r.Lengths[i].Value < r.Lengths[j].Value })
+	return r
+}
+
+// WriteJSON writes the report in JSON format.
+func (r *QCReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteTSV writes the report in tab-delimited sections like the
+// fastqc_data.txt of FastQC, i.e., a section starts with ">>name",
+// followed by a header line starting with "#", and ends with ">>END_MODULE".
+func (r *QCReport) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, ">>Basic Statistics\n#Measure\tValue\n")
+	fmt.Fprintf(bw, "Encoding\t%s\nReads\t%d\nBases\t%d\n", r.Encoding, r.Reads, r.Bases)
+	fmt.Fprintf(bw, "GC content\t%.4f\nN content\t%.4f\nDuplication rate\t%.4f\n>>END_MODULE\n",
+		r.GCContent, r.NContent, r.DuplicationRate)
+
+	fmt.Fprintf(bw, ">>Per position statistics\n#Position\tMean\tP10\tQ1\tMedian\tQ3\tP90\tA\tC\tG\tT\tN\n")
+	for _, p := range r.PerPosition {
+		fmt.Fprintf(bw, "%d\t%.2f\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			p.Position, p.Mean, p.P10, p.Q1, p.Median, p.Q3, p.P90, p.A, p.C, p.G, p.T, p.N)
+	}
+	fmt.Fprintf(bw, ">>END_MODULE\n")
+
+	for _, section := range []struct {
+		name, header string
+		data         []ValueCount
+	}{
+		{"Per sequence quality scores", "Quality", r.MeanQualities},
+		{"Per sequence GC content", "GC Content", r.GCContents},
+		{"Sequence Length Distribution", "Length", r.Lengths},
+	} {
+		fmt.Fprintf(bw, ">>%s\n#%s\tCount\n", section.name, section.header)
+		for _, v := range section.data {
+			fmt.Fprintf(bw, "%d\t%d\n", v.Value, v.Count)
+		}
+		fmt.Fprintf(bw, ">>END_MODULE\n")
+	}
+
+	fmt.Fprintf(bw, ">>Overrepresented sequences\n#Sequence\tCount\tPercentage\n")
+	for _, o := range r.Overrepresented {
+		fmt.Fprintf(bw, "%s\t%d\t%.4f\n", o.Seq, o.Count, o.Fraction*100)
+	}
+	fmt.Fprintf(bw, ">>END_MODULE\n")
+
+	return bw.Flush()
+}
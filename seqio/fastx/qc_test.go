@@ -0,0 +1,89 @@
+package fastx
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func TestQCStats(t *testing.T) {
+	reads := [][2]string{
+		{"ACGTACGTAC", "IIIII55555"},
+		{"ACGTACGTAC", "IIIII55555"},
+		{"GGGGCCCCNN", "?????!!!!!"},
+		{"ATATAT", "++++++"},
+	}
+	records := make([]*Record, len(reads))
+	for i, r := range reads {
+		records[i], _ = NewRecordWithQual(seq.DNAredundant, []byte("r"), []byte("r"), nil, []byte(r[0]), []byte(r[1]))
+	}
+
+	s := NewQCStats(seq.Sanger)
+	for _, r := range records {
+		s.Add(r)
+	}
+	if s.Reads() != 4 || s.Bases() != 36 {
+		t.Errorf("reads/bases error: %d, %d", s.Reads(), s.Bases())
+	}
+	if math.Abs(s.GCContent()-18.0/34) > 1e-9 || math.Abs(s.NContent()-2.0/36) > 1e-9 {
+		t.Errorf("GC/N content error: %f, %f", s.GCContent(), s.NContent())
+	}
+	if math.Abs(s.DuplicationRate()-0.25) > 1e-9 {
+		t.Errorf("duplication rate error: %f", s.DuplicationRate())
+	}
+	over := s.Overrepresented(0.001)
+	if len(over) != 1 || over[0].Seq != "ACGTACGTAC" || over[0].Count != 2 {
+		t.Errorf("overrepresented sequences error: %v", over)
+	}
+
+	p := s.Position(0)
+	if p.A != 3 || p.G != 1 || p.Median != 30 || p.P10 != 10 || p.Q1 != 10 || p.Q3 != 40 {
+		t.Errorf("position statistics error: %+v", p)
+	}
+	p = s.Position(9)
+	if p.C != 2 || p.N != 1 || p.Median != 20 || p.Mean != 40.0/3 {
+		t.Errorf("position statistics error: %+v", p)
+	}
+
+	// merging
+	s1, s2 := NewQCStats(seq.Sanger), NewQCStats(seq.Sanger)
+	s1.Add(records[0])
+	s1.Add(records[3])
+	s2.Add(records[1])
+	s2.Add(records[2])
+	if err := s1.Merge(s2); err != nil {
+		t.Error(err)
+		return
+	}
+	var b1, b2 bytes.Buffer
+	s.Report().WriteJSON(&b1)
+	s1.Report().WriteJSON(&b2)
+	if b1.String() != b2.String() {
+		t.Errorf("merged stats differ:\n%s\n%s", b1.String(), b2.String())
+	}
+	if err := s1.Merge(NewQCStats(seq.Illumina1p3)); err == nil {
+		t.Errorf("unmatched encodings should be reported")
+	}
+
+	var r QCReport
+	if err := json.Unmarshal(b1.Bytes(), &r); err != nil {
+		t.Error(err)
+		return
+	}
+	if r.Reads != 4 || len(r.PerPosition) != 10 || len(r.Lengths) != 2 {
+		t.Errorf("JSON report error: %+v", r)
+	}
+
+	b1.Reset()
+	if err := s.Report().WriteTSV(&b1); err != nil {
+		t.Error(err)
+		return
+	}
+	if bytes.Count(b1.Bytes(), []byte(">>END_MODULE")) != 6 ||
+		!bytes.Contains(b1.Bytes(), []byte("ACGTACGTAC\t2\t50.0000")) {
+		t.Errorf("TSV report error:\n%s", b1.String())
+	}
+}
@@ -0,0 +1,231 @@
+package fastx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+// ErrAmbiguousQualityEncoding means the quality encoding can't be determined
+// from the reads checked, e.g., Illumina 1.3+ and Illumina 1.5+ when there
+// are no qualities below 'B' and 'B' is not enriched, see DetectQualityEncoding.
+// Please set the encoding with Reader.SetQualityEncoding.
+var ErrAmbiguousQualityEncoding = errors.New("fastx: ambiguous quality encoding")
+
+// DefaultQualityDetectionReads is the number of reads for detecting quality
+// encoding when it's not detected before normalizing qualities.
+var DefaultQualityDetectionReads = 1000
+
+// QualityEncodingGuess is the result of detecting quality encoding.
+type QualityEncodingGuess struct {
+	// Encoding is the detected encoding, Unknown for FASTA files,
+	// empty files and ambiguous cases.
+	Encoding seq.QualityEncoding
+	// Candidates are all possible encodings, more than one for ambiguous cases.
+	Candidates []seq.QualityEncoding
+
+	Reads    int  // number of reads with qualities checked
+	Min, Max byte // range of quality letters
+}
+
+// pendingRecord is a record read ahead.
+type pendingRecord struct {
+	record    *Record
+	err       error
+	fixReport *seq.ValidationReport
+}
+
+// DetectQualityEncoding detects the quality encoding from the first n
+// (0 for DefaultQualityDetectionReads) FASTQ records, which are read ahead
+// and still returned by later calls of Read. It's only performed once,
+// and the result of the first call is returned afterwards.
+//
+// Qualities with letters below ';' are Phred+33, reported as Illumina1p8
+// if no letters are above 'J' (Phred 41), or Sanger otherwise. Others are
+// Phred+64, reported as Solexa if letters below '@' exist, Illumina1p3 if
+// '@' or 'A' exist, and Illumina1p5 if 'B' is among the seq.NMostCommonThreshold
+// most common letters. Other cases are ambiguous, i.e., Illumina 1.3+ or 1.5+,
+// and high Phred+33 qualities with no letters above 'J'. For them,
+// ErrAmbiguousQualityEncoding is returned and the candidates are reported.
+func (fastxReader *Reader) DetectQualityEncoding(n int) (*QualityEncodingGuess, error) {
+	if fastxReader.qualDetected {
+		return fastxReader.qualGuess, fastxReader.qualErr
+	}
+	if n <= 0 {
+		n = DefaultQualityDetectionReads
+	}
+
+	var count [256]int
+	guess := &QualityEncodingGuess{Encoding: seq.Unknown}
+	var record *Record
+	var err error
+	for guess.Reads < n {
+		record, err = fastxReader.read()
+		if err == io.EOF {
+			break
+		}
+		if record != nil {
+			record = record.Clone()
+		}
+		fastxReader.pending = append(fastxReader.pending, pendingRecord{record: record, err: err,
+			fixReport: fastxReader.fixReport})
+		if err != nil {
+			break
+		}
+		if len(record.Seq.Qual) == 0 {
+			if !fastxReader.IsFastq {
+				break
+			}
+			continue
+		}
+		for _, q := range record.Seq.Qual {
+			count[q]++
+		}
+		guess.Reads++
+	}
+
+	fastxReader.qualDetected = true
+	fastxReader.qualGuess = guess
+	if guess.Reads == 0 {
+		return guess, nil
+	}
+
+	guess.Min, guess.Max = 255, 0
+	for q, c := range count {
+		if c == 0 {
+			continue
+		}
+		if byte(q) < guess.Min {
+			guess.Min = byte(q)
+		}
+		if byte(q) > guess.Max {
+			guess.Max = byte(q)
+		}
+	}
+
+	guess.Candidates = qualityEncodingCandidates(&count, guess.Min, guess.Max)
+	switch len(guess.Candidates) {
+	case 0:
+		fastxReader.qualErr = fmt.Errorf("fastx: %w: qualities out of range: %c-%c",
+			seq.ErrUnknownQualityEncoding, guess.Min, guess.Max)
+	case 1:
+		guess.Encoding = guess.Candidates[0]
+	default:
+		fastxReader.qualErr = fmt.Errorf("%w: %v, qualities: %c-%c",
+			ErrAmbiguousQualityEncoding, guess.Candidates, guess.Min, guess.Max)
+	}
+	return guess, fastxReader.qualErr
+}
+
+// qualityEncodingCandidates returns possible encodings from the counts of quality letters.
+func qualityEncodingCandidates(count *[256]int, min, max byte) []seq.QualityEncoding {
+	if min < 33 || max > 126 {
+		return nil
+	}
+
+	if min < 59 { // Phred+33
+		if max <= 'J' {
+			return []seq.QualityEncoding{seq.Illumina1p8}
+		}
+		return []seq.QualityEncoding{seq.Sanger}
+	}
+
+	// Phred+64, while Phred+33 ones of Illumina 1.8+ with all qualities
+	// above ';' (Phred 26) are also possible if no letters are above 'J'.
+	var encodings []seq.QualityEncoding
+	if max <= 'J' {
+		encodings = append(encodings, seq.Illumina1p8)
+	}
+	switch {
+	case min < '@':
+		encodings = append(encodings, seq.Solexa)
+	case min < 'B':
+		encodings = append(encodings, seq.Illumina1p3)
+	case bEnriched(count):
+		encodings = append(encodings, seq.Illumina1p5)
+	default:
+		encodings = append(encodings, seq.Illumina1p3, seq.Illumina1p5)
+	}
+	return encodings
+}
+
+// bEnriched tells whether 'B' is among the seq.NMostCommonThreshold
+// most common quality letters, i.e., the Read Segment Quality Control
+// Indicator of Illumina 1.5+.
+func bEnriched(count *[256]int) bool {
+	if count['B'] == 0 {
+		return false
+	}
+	letters := make([]int, 0, 94)
+	for q, c := range count {
+		if c > 0 {
+			letters = append(letters, q)
+		}
+	}
+	sort.SliceStable(letters, func(i, j int) bool {
+		return count[letters[i]] > count[letters[j]]
+	})
+	for i := 0; i < len(letters) && i < seq.NMostCommonThreshold; i++ {
+		if letters[i] == 'B' {
+			return true
+		}
+	}
+	return false
+}
+
+// QualityEncoding returns the quality encoding detected by
+// DetectQualityEncoding or set by SetQualityEncoding,
+// Unknown if it's not detected yet or is ambiguous.
+func (fastxReader *Reader) QualityEncoding() seq.QualityEncoding {
+	if fastxReader.qualGuess == nil {
+		return seq.Unknown
+	}
+	return fastxReader.qualGuess.Encoding
+}
+
+// SetQualityEncoding sets the quality encoding of the file,
+// and the detection is skipped. It's useful for ambiguous cases.
+func (fastxReader *Reader) SetQualityEncoding(encoding seq.QualityEncoding) {
+	fastxReader.qualDetected = true
+	fastxReader.qualErr = nil
+	var guess QualityEncodingGuess
+	if fastxReader.qualGuess != nil {
+		guess = *fastxReader.qualGuess
+	}
+	guess.Encoding = encoding
+	guess.Candidates = []seq.QualityEncoding{encoding}
+	fastxReader.qualGuess = &guess
+}
+
+// SetQualityNormalization makes the reader convert qualities of all records
+// to the encoding, e.g., seq.Sanger or seq.Illumina1p8, and seq.Unknown for
+// disabling it. Force means truncating scores > 40 to 40 when converting
+// Illumina-1.8+ to Sanger, see seq.QualityConvert.
+//
+// The source encoding is detected with DefaultQualityDetectionReads reads
+// on the first call of Read, unless DetectQualityEncoding or SetQualityEncoding
+// is called before. Read returns the detection error in ambiguous cases.
+func (fastxReader *Reader) SetQualityNormalization(encoding seq.QualityEncoding, force bool) {
+	fastxReader.qualTo = encoding
+	fastxReader.qualForce = force
+}
+
+// normalizeQuality converts the quality of the record to the target encoding.
+func (fastxReader *Reader) normalizeQuality(record *Record) error {
+	from := fastxReader.QualityEncoding()
+	if len(record.Seq.Qual) == 0 || from == seq.Unknown {
+		return nil
+	}
+	if from == fastxReader.qualTo && !fastxReader.qualForce {
+		return nil
+	}
+	qual, err := seq.QualityConvert(from, fastxReader.qualTo, record.Seq.Qual, fastxReader.qualForce)
+	if err != nil {
+		return fmt.Errorf("fastx: %s: %w", record.ID, err)
+	}
+	record.Seq.Qual = qual
+	return nil
+}
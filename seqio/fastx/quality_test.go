@@ -0,0 +1,123 @@
+package fastx
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func readAllRecords(reader *Reader) ([]*Record, error) {
+	var records []*Record
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return records, err
+		}
+		records = append(records, record.Clone())
+	}
+}
+
+func TestDetectQualityEncoding(t *testing.T) {
+	tests := []struct {
+		data      string
+		encoding  seq.QualityEncoding
+		ambiguous bool
+	}{
+		{"@r1\nACGT\n+\n!5?I\n", seq.Illumina1p8, false},
+		{"@r1\nACGT\n+\n!5?~\n", seq.Sanger, false},
+		{"@r1\nACGT\n+\n;@Th\n", seq.Solexa, false},
+		{"@r1\nACGT\n+\n@ITh\n", seq.Illumina1p3, false},
+		{"@r1\nACGT\n+\nhhBB\n@r2\nACGT\n+\nTBBB\n", seq.Illumina1p5, false},
+		{"@r1\nACGT\n+\nBThh\n@r2\nACGT\n+\nhhTh\n", seq.Unknown, true},
+		{"@r1\nACGT\n+\nFGHJ\n", seq.Unknown, true},
+		{">r1\nACGT\n", seq.Unknown, false},
+	}
+	for i, test := range tests {
+		reader, err := NewReaderFromIO(seq.DNA, strings.NewReader(test.data), "")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		guess, err := reader.DetectQualityEncoding(0)
+		if test.ambiguous != errors.Is(err, ErrAmbiguousQualityEncoding) {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		}
+		if test.ambiguous && len(guess.Candidates) < 2 {
+			t.Errorf("#%d: candidates of ambiguous cases should be reported: %v", i, guess.Candidates)
+		}
+		if reader.QualityEncoding() != test.encoding {
+			t.Errorf("#%d: encoding error: expected %s, returned %s", i, test.encoding, reader.QualityEncoding())
+		}
+
+		// records read ahead are still returned.
+		records, err := readAllRecords(reader)
+		if err != nil {
+			t.Errorf("#%d: %s", i, err)
+		}
+		if n := strings.Count(test.data, "\n+\n") + strings.Count(test.data, ">"); len(records) != n {
+			t.Errorf("#%d: expected %d records, returned %d", i, n, len(records))
+		}
+		reader.Close()
+	}
+}
+
+func TestQualityNormalization(t *testing.T) {
+	data := "@r1\nACGT\n+\n@ITh\n@r2\nACGT\n+\nhhhh\n@r3\nAC\n+\nBh\n"
+
+	reader, err := NewReaderFromIO(seq.DNA, strings.NewReader(data), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reader.SetQualityNormalization(seq.Sanger, false)
+	if _, err = reader.DetectQualityEncoding(1); err != nil {
+		t.Error(err)
+		return
+	}
+	records, err := readAllRecords(reader)
+	reader.Close()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := []string{"!*5I", "IIII", "#I"}
+	if len(records) != len(expected) {
+		t.Errorf("expected %d records, returned %d", len(expected), len(records))
+		return
+	}
+	for i, record := range records {
+		if string(record.Seq.Qual) != expected[i] {
+			t.Errorf("normalized quality error: expected %s, returned %s", expected[i], record.Seq.Qual)
+		}
+	}
+
+	// ambiguous cases are reported
+	data = "@r1\nACGTAC\n+\nBTThhh\n"
+	reader, err = NewReaderFromIO(seq.DNA, strings.NewReader(data), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer reader.Close()
+	reader.SetQualityNormalization(seq.Illumina1p8, false)
+	if _, err = reader.Read(); !errors.Is(err, ErrAmbiguousQualityEncoding) {
+		t.Errorf("ambiguous quality encoding should be reported: %v", err)
+		return
+	}
+
+	reader.SetQualityEncoding(seq.Illumina1p5)
+	record, err := reader.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(record.Seq.Qual) != "!55III" {
+		t.Errorf("normalized quality error: %s", record.Seq.Qual)
+	}
+}
@@ -69,6 +69,17 @@ type Reader struct {
 	qualBuffer      *bytes.Buffer
 	record          *Record
 
+	fixPolicy      seq.FixPolicy         // policy of fixing invalid letters, 0 for none
+	fixReplacement byte                  // replacement letter for seq.FixReplace
+	fixReport      *seq.ValidationReport // report of invalid letters of the current record
+
+	pending      []pendingRecord // records read ahead for detecting quality encoding
+	qualDetected bool            // whether the quality encoding is detected or set
+	qualGuess    *QualityEncodingGuess
+	qualErr      error               // error of detecting quality encoding
+	qualTo       seq.QualityEncoding // target encoding of quality normalization, Unknown for none
+	qualForce    bool
+
 	// only for compatibility of empty files
 	Err error
 }
@@ -96,10 +107,56 @@ func (fastxReader *Reader) Reset() {
 	fastxReader.head = nil
 	fastxReader.seq = nil
 	fastxReader.qual = nil
+	fastxReader.record.Seq.Qual = nil // FASTA records don't set it
+
+	fastxReader.fixPolicy = 0
+	fastxReader.fixReplacement = 0
+	fastxReader.fixReport = nil
+
+	fastxReader.pending = nil
+	fastxReader.qualDetected = false
+	fastxReader.qualGuess = nil
+	fastxReader.qualErr = nil
+	fastxReader.qualTo = seq.Unknown
+	fastxReader.qualForce = false
 
 	fastxReader.Err = nil
 }
 
+// SetFixPolicy makes the reader fix invalid letters of records with the policy,
+// instead of returning an error. replacement is the letter for seq.FixReplace,
+// 0 for the default one (N for nucleotides and X for proteins).
+// Records with invalid letters remaining after fixing are still reported as errors.
+// It only works when seq.ValidateSeq is true.
+func (fastxReader *Reader) SetFixPolicy(policy seq.FixPolicy, replacement byte) {
+	fastxReader.fixPolicy = policy
+	fastxReader.fixReplacement = replacement
+}
+
+// FixReport returns the report of invalid letters of the current record
+// before fixing, and nil if the record is valid or no fix policy is set.
+func (fastxReader *Reader) FixReport() *seq.ValidationReport {
+	return fastxReader.fixReport
+}
+
+// validate checks the sequence of the current record, and fixes it if needed.
+func (fastxReader *Reader) validate() error {
+	fastxReader.fixReport = nil
+	err := fastxReader.t.IsValid(fastxReader.seq)
+	if err == nil || fastxReader.fixPolicy == 0 {
+		return err
+	}
+	fastxReader.fixReport, err = fastxReader.record.Seq.Fix(fastxReader.fixPolicy, fastxReader.fixReplacement)
+	fastxReader.seq = fastxReader.record.Seq.Seq
+	if fastxReader.IsFastq {
+		fastxReader.qual = fastxReader.record.Seq.Qual
+	}
+	if err != nil {
+		return fmt.Errorf("fastx: %s: %s", fastxReader.record.ID, err)
+	}
+	return nil
+}
+
 // regexp for checking idRegexp string.
 // The regular expression must contain "(" and ")" to capture matched ID
 var reCheckIDregexpStr = regexp.MustCompile(`\(.+\)`)
@@ -230,7 +287,38 @@ func (fastxReader *Reader) close() {
 // Note that, similar to bytes.Buffer.Bytes() method,
 // the current record will change after your another call of this method.
 // So, you could use record.Clone() to make a copy.
+//
+// If quality normalization is set by SetQualityNormalization,
+// qualities of FASTQ records are converted to the target encoding.
 func (fastxReader *Reader) Read() (*Record, error) {
+	if fastxReader.qualTo != seq.Unknown {
+		if !fastxReader.qualDetected {
+			fastxReader.DetectQualityEncoding(DefaultQualityDetectionReads)
+		}
+		if fastxReader.qualErr != nil {
+			return nil, fastxReader.qualErr
+		}
+	}
+
+	var record *Record
+	var err error
+	if len(fastxReader.pending) > 0 {
+		record, err = fastxReader.pending[0].record, fastxReader.pending[0].err
+		fastxReader.fixReport = fastxReader.pending[0].fixReport
+		fastxReader.pending[0] = pendingRecord{}
+		fastxReader.pending = fastxReader.pending[1:]
+	} else {
+		record, err = fastxReader.read()
+	}
+
+	if err != nil || fastxReader.qualTo == seq.Unknown {
+		return record, err
+	}
+	return record, fastxReader.normalizeQuality(record)
+}
+
+// read parses one FASTA/Q record from the file.
+func (fastxReader *Reader) read() (*Record, error) {
 	if fastxReader.lastPart && fastxReader.finished {
 		return nil, io.EOF
 	}
@@ -448,7 +536,7 @@ func (fastxReader *Reader) parseRecord() (bool, error) {
 		fastxReader.record.Seq.Qual = fastxReader.qual
 
 		if seq.ValidateSeq {
-			err = fastxReader.t.IsValid(fastxReader.seq)
+			err = fastxReader.validate()
 		}
 
 		if len(fastxReader.seq) != len(fastxReader.qual) {
@@ -463,7 +551,7 @@ func (fastxReader *Reader) parseRecord() (bool, error) {
 		fastxReader.record.Seq.Seq = fastxReader.seq
 
 		if seq.ValidateSeq {
-			err = fastxReader.t.IsValid(fastxReader.seq)
+			err = fastxReader.validate()
 		}
 	}
 
@@ -3,8 +3,13 @@ package fastx
 import (
 	// "fmt"
 
+	"errors"
 	"io"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/shenwei356/bio/seq"
 )
 
 func TestFastaReader2(t *testing.T) {
@@ -196,3 +201,155 @@ func TestEmptyFile(t *testing.T) {
 		return
 	}
 }
+
+func TestReaderFixPolicy(t *testing.T) {
+	data := "@r1\nACGTZAC\n+\nABCDEFG\n@r2\nACGT\n+\nABCD\n"
+
+	// abort by default
+	reader, err := NewReaderFromIO(seq.DNA, strings.NewReader(data), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = reader.Read(); err == nil {
+		t.Errorf("invalid letters should be reported")
+	}
+	reader.Close()
+
+	reader, err = NewReaderFromIO(seq.DNA, strings.NewReader(data), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer reader.Close()
+	reader.SetFixPolicy(seq.FixDrop, 0)
+
+	record, err := reader.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(record.Seq.Seq) != "ACGTAC" || string(record.Seq.Qual) != "ABCDFG" {
+		t.Errorf("fixing record error: %s, %s", record.Seq.Seq, record.Seq.Qual)
+	}
+	if r := reader.FixReport(); r == nil || r.Total() != 1 || r.Invalid[0].First != 4 {
+		t.Errorf("fix report error: %v", r)
+	}
+
+	record, err = reader.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(record.Seq.Seq) != "ACGT" || reader.FixReport() != nil {
+		t.Errorf("valid record should not be fixed")
+	}
+}
+
+func TestReaderFixPolicyWithQualityNormalization(t *testing.T) {
+	// records are read ahead for detecting the quality encoding,
+	// fix reports should still be the ones of returned records.
+	data := "@r1\nACGTZAC\n+\n5555555\n@r2\nACGT\n+\n5555\n@r3\nAZZT\n+\n5555\n"
+	reader, err := NewReaderFromIO(seq.DNA, strings.NewReader(data), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer reader.Close()
+	reader.SetFixPolicy(seq.FixDrop, 0)
+	reader.SetQualityNormalization(seq.Sanger, false)
+
+	expected := []struct {
+		seq     string
+		invalid int
+	}{
+		{"ACGTAC", 1},
+		{"ACGT", 0},
+		{"AT", 2},
+	}
+	for i, e := range expected {
+		record, err := reader.Read()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if string(record.Seq.Seq) != e.seq {
+			t.Errorf("#%d: fixing record error: %s", i, record.Seq.Seq)
+		}
+		r := reader.FixReport()
+		if (e.invalid == 0 && r != nil) || (e.invalid > 0 && (r == nil || r.Total() != e.invalid)) {
+			t.Errorf("#%d: fix report error: %v", i, r)
+		}
+	}
+}
+
+// runStage runs a record-processing stage over records parsed from the data,
+// where the stage calls emit for output records, and returns names of emitted
+// records joined by ",".
+func runStage(t *testing.T, data string, stage func(reader *Reader, emit func(record *Record) error) error) (string, error) {
+	reader, err := NewReaderFromIO(seq.DNAredundant, strings.NewReader(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var names []string
+	err = stage(reader, func(record *Record) error {
+		names = append(names, string(record.Name))
+		return nil
+	})
+	return strings.Join(names, ","), err
+}
+
+func TestStageErrors(t *testing.T) {
+	dir := t.TempDir()
+	grepper, _ := NewGrepper(&GrepOptions{Patterns: []string{"r1"}, Invert: true})
+	stages := []struct {
+		name  string
+		stage func(reader *Reader, emit func(record *Record) error) error
+	}{
+		{"dedup", func(reader *Reader, emit func(*Record) error) error {
+			d, _ := NewDeduplicator(nil)
+			return d.Filter(reader, emit)
+		}},
+		{"sort", func(reader *Reader, emit func(*Record) error) error {
+			return SortReader(reader, nil, emit)
+		}},
+		{"partition", func(reader *Reader, emit func(*Record) error) error {
+			_, err := PartitionReader(reader, &PartitionOptions{Mode: PartitionByCount, Records: 1,
+				OutPrefix: filepath.Join(dir, "part.")})
+			return err
+		}},
+		{"sample", func(reader *Reader, emit func(*Record) error) error {
+			s, _ := NewSampler(&SampleOptions{Proportion: 1})
+			return s.Sample(reader, emit)
+		}},
+		{"reservoir sample", func(reader *Reader, emit func(*Record) error) error {
+			s, _ := NewSampler(&SampleOptions{Number: 10})
+			return s.Sample(reader, emit)
+		}},
+		{"grep", func(reader *Reader, emit func(*Record) error) error {
+			_, err := grepper.Grep(reader, emit)
+			return err
+		}},
+	}
+
+	errStop := errors.New("stop")
+	for _, s := range stages {
+		// errors of the reader
+		if _, err := runStage(t, "@r0\nACGT\n+\nIIII\n@r1\nACGT\n+\nII\n", s.stage); err == nil {
+			t.Errorf("%s: error of the reader should be returned", s.name)
+		}
+
+		// errors of fn
+		if s.name == "partition" { // no records are emitted
+			continue
+		}
+		_, err := runStage(t, "@r0\nACGT\n+\nIIII\n@r2\nACGT\n+\nIIII\n", func(reader *Reader, emit func(*Record) error) error {
+			return s.stage(reader, func(record *Record) error { return errStop })
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("%s: error of fn should be returned: %v", s.name, err)
+		}
+	}
+}
@@ -27,6 +27,25 @@ func (record *Record) Clone() *Record {
 	}
 }
 
+// TrimByQuality trims the sequence and quality of the record in place,
+// see seq.Seq.TrimByQuality.
+func (record *Record) TrimByQuality(opt *seq.TrimOptions) (start, end int, err error) {
+	start, end, err = record.Seq.TrimByQuality(opt)
+	if err != nil {
+		return start, end, fmt.Errorf("%s: %w", record.ID, err)
+	}
+	return start, end, nil
+}
+
+// Filter checks the record with filters, see seq.Seq.Filter.
+func (record *Record) Filter(opt *seq.FilterOptions) (seq.FilterResult, error) {
+	r, err := record.Seq.Filter(opt)
+	if err != nil {
+		return r, fmt.Errorf("%s: %w", record.ID, err)
+	}
+	return r, nil
+}
+
 func (record *Record) String() string {
 	return string(record.Format(60))
 }
@@ -0,0 +1,47 @@
+package fastx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func TestRecordTrimAndFilter(t *testing.T) {
+	reader, err := NewReaderFromIO(seq.DNAredundant, strings.NewReader("@r1\nACGTACGTA\n+\n++5?I?5++\n"), "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer reader.Close()
+
+	record, err := reader.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, _, err = record.TrimByQuality(&seq.TrimOptions{Leading: 20, Trailing: 20}); err != nil {
+		t.Error(err)
+		return
+	}
+	if string(record.Seq.Seq) != "GTACG" || string(record.Seq.Qual) != "5?I?5" {
+		t.Errorf("trimming record error: %s, %s", record.Seq.Seq, record.Seq.Qual)
+	}
+
+	r, err := record.Filter(&seq.FilterOptions{MinLen: 6})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if r != seq.FilterTooShort {
+		t.Errorf("filtering record error: %s", r)
+	}
+
+	// nil options
+	if _, _, err = record.TrimByQuality(nil); err != nil || string(record.Seq.Seq) != "GTACG" {
+		t.Errorf("trimming record with nil options error: %v, %s", err, record.Seq.Seq)
+	}
+	if r, err = record.Filter(nil); err != nil || r != seq.FilterPass {
+		t.Errorf("filtering record with nil options error: %v, %s", err, r)
+	}
+}
@@ -0,0 +1,154 @@
+package fastx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+// ErrUnpairedRecords means paired-end files have different numbers of records.
+var ErrUnpairedRecords = errors.New("fastx: unequal numbers of paired-end records")
+
+// SampleOptions contains the options of sampling records,
+// either Proportion or Number should be given.
+type SampleOptions struct {
+	Seed int64 // random seed, the same seed gives the same records
+
+	// Proportion in (0, 1] is for the proportion mode, where each record
+	// is kept with the probability.
+	Proportion float64
+	// Number > 0 is for the reservoir mode, where a fixed number of records
+	// are sampled in one pass, with memory bounded by the number.
+	Number int
+}
+
+// Sampler samples records reproducibly. Records are outputted in the order
+// of input in both modes. Each call of Sample or SamplePairs starts over
+// with the seed, so the same input gives the same records.
+// Sampler is not safe for concurrent use.
+type Sampler struct {
+	opt SampleOptions
+	rng *rand.Rand
+
+	n uint64 // number of records or pairs checked
+}
+
+// NewSampler creates a Sampler. Nil opt is treated as empty options,
+// for which an error is returned as neither proportion nor number is given.
+func NewSampler(opt *SampleOptions) (*Sampler, error) {
+	if opt == nil {
+		opt = &SampleOptions{}
+	}
+	if (opt.Proportion > 0) == (opt.Number > 0) {
+		return nil, fmt.Errorf("fastx: either proportion or number should be given for sampling")
+	}
+	if opt.Proportion > 1 || opt.Proportion < 0 {
+		return nil, fmt.Errorf("fastx: invalid proportion for sampling: %f", opt.Proportion)
+	}
+	return &Sampler{opt: *opt, rng: rand.New(rand.NewSource(opt.Seed))}, nil
+}
+
+// Records returns the number of records (or pairs) checked in the last call.
+func (s *Sampler) Records() uint64 { return s.n }
+
+// sampledRecords are records in the reservoir.
+type sampledRecords struct {
+	index   uint64
+	records []*Record
+}
+
+// sample reads records with next, and calls fn for sampled ones.
+func (s *Sampler) sample(next func() ([]*Record, error), fn func(records []*Record) error) error {
+	s.rng.Seed(s.opt.Seed)
+	s.n = 0
+
+	var reservoir []sampledRecords
+	var records []*Record
+	var j int64
+	var err error
+	for {
+		records, err = next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		s.n++
+
+		if s.opt.Proportion > 0 {
+			if s.rng.Float64() < s.opt.Proportion {
+				if err = fn(records); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// reservoir, algorithm R
+		if len(reservoir) < s.opt.Number {
+			j = int64(len(reservoir))
+			reservoir = append(reservoir, sampledRecords{})
+		} else if j = s.rng.Int63n(int64(s.n)); j >= int64(s.opt.Number) {
+			continue
+		}
+		reservoir[j].index = s.n
+		reservoir[j].records = reservoir[j].records[:0]
+		for _, r := range records {
+			reservoir[j].records = append(reservoir[j].records, r.Clone())
+		}
+	}
+
+	sort.Slice(reservoir, func(i, j int) bool { return reservoir[i].index < reservoir[j].index })
+	for _, r := range reservoir {
+		if err = fn(r.records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sample reads all records from the reader, and calls fn for sampled ones.
+// In the proportion mode, records are only valid during the calls,
+// please clone them to keep. The reader is not closed.
+func (s *Sampler) Sample(reader *Reader, fn func(record *Record) error) error {
+	buf := make([]*Record, 1)
+	return s.sample(
+		func() ([]*Record, error) {
+			record, err := reader.Read()
+			buf[0] = record
+			return buf, err
+		},
+		func(records []*Record) error { return fn(records[0]) },
+	)
+}
+
+// SamplePairs samples paired-end records from two readers identically,
+// and ErrUnpairedRecords is returned if the numbers of records differ.
+// IDs of records are not checked. The readers are not closed.
+func (s *Sampler) SamplePairs(reader1, reader2 *Reader, fn func(record1, record2 *Record) error) error {
+	buf := make([]*Record, 2)
+	return s.sample(
+		func() ([]*Record, error) {
+			record1, err1 := reader1.Read()
+			record2, err2 := reader2.Read()
+			if err1 != nil && err1 != io.EOF {
+				return nil, err1
+			}
+			if err2 != nil && err2 != io.EOF {
+				return nil, err2
+			}
+			if err1 == io.EOF && err2 == io.EOF {
+				return nil, io.EOF
+			}
+			if err1 == io.EOF || err2 == io.EOF {
+				return nil, ErrUnpairedRecords
+			}
+			buf[0], buf[1] = record1, record2
+			return buf, nil
+		},
+		func(records []*Record) error { return fn(records[0], records[1]) },
+	)
+}
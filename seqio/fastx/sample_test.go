@@ -0,0 +1,138 @@
+package fastx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func sampleData(n int, mate int) string {
+	var buf strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&buf, "@r%d/%d\nACGT\n+\nIIII\n", i, mate)
+	}
+	return buf.String()
+}
+
+func TestSampler(t *testing.T) {
+	data := sampleData(100, 1)
+	sample := func(s *Sampler) string {
+		names, err := runStage(t, data, s.Sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Records() != 100 {
+			t.Errorf("records checked error: %d", s.Records())
+		}
+		return names
+	}
+
+	for _, opt := range []SampleOptions{{Seed: 11, Proportion: 0.3}, {Seed: 11, Number: 10}} {
+		s, err := NewSampler(&opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := sample(s)
+		if out2 := sample(s); out != out2 { // reused sampler
+			t.Errorf("%+v: sampling with a reused sampler is not reproducible", opt)
+		}
+		s2, _ := NewSampler(&opt)
+		if out != sample(s2) {
+			t.Errorf("%+v: sampling is not reproducible", opt)
+		}
+		opt2 := opt
+		opt2.Seed++
+		s2, _ = NewSampler(&opt2)
+		if out == sample(s2) {
+			t.Errorf("%+v: different seeds should give different results", opt)
+		}
+
+		n := len(strings.Split(out, ","))
+		if opt.Number > 0 && n != opt.Number {
+			t.Errorf("reservoir sampling error: %d records", n)
+		}
+		if opt.Proportion > 0 && (n < 10 || n > 50) {
+			t.Errorf("proportion sampling error: %d records", n)
+		}
+	}
+
+	// all records are kept if there are fewer records than the number
+	s, _ := NewSampler(&SampleOptions{Number: 200})
+	if out := sample(s); len(strings.Split(out, ",")) != 100 || !strings.HasPrefix(out, "r1/1,r2/1,") {
+		t.Errorf("reservoir sampling error: %s", out)
+	}
+
+	for _, opt := range []SampleOptions{{Proportion: 0.1, Number: 10}, {}, {Proportion: 1.5}} {
+		if _, err := NewSampler(&opt); err == nil {
+			t.Errorf("invalid options should be reported: %+v", opt)
+		}
+	}
+	if _, err := NewSampler(nil); err == nil {
+		t.Errorf("nil options should be reported")
+	}
+}
+
+func TestSamplerPairs(t *testing.T) {
+	pairs := func(s *Sampler, data1, data2 string) (string, error) {
+		reader2, err := NewReaderFromIO(nil, strings.NewReader(data2), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader2.Close()
+		return runStage(t, data1, func(reader1 *Reader, emit func(*Record) error) error {
+			return s.SamplePairs(reader1, reader2, func(r1, r2 *Record) error {
+				id1, id2 := string(r1.ID), string(r2.ID)
+				if id1[:len(id1)-2] != id2[:len(id2)-2] {
+					t.Errorf("unpaired records: %s, %s", id1, id2)
+				}
+				return emit(r1)
+			})
+		})
+	}
+
+	data1, data2 := sampleData(50, 1), sampleData(50, 2)
+	for _, opt := range []SampleOptions{{Seed: 1, Proportion: 0.2}, {Seed: 1, Number: 7}} {
+		s, _ := NewSampler(&opt)
+		out, err := pairs(s, data1, data2)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var last int
+		for _, id := range strings.Split(out, ",") {
+			var i int
+			fmt.Sscanf(id, "r%d/1", &i)
+			if i <= last {
+				t.Errorf("records not in the input order: %s", id)
+			}
+			last = i
+		}
+		if opt.Number > 0 && len(strings.Split(out, ",")) != opt.Number {
+			t.Errorf("reservoir sampling error: %s", out)
+		}
+
+		// the same as sampling single-end reads
+		s2, _ := NewSampler(&opt)
+		if out2, _ := runStage(t, data1, s2.Sample); out2 != out {
+			t.Errorf("%+v: paired-end sampling differs from single-end: %s, %s", opt, out, out2)
+		}
+	}
+
+	s, _ := NewSampler(&SampleOptions{Proportion: 1})
+	if _, err := pairs(s, sampleData(5, 1), sampleData(4, 2)); !errors.Is(err, ErrUnpairedRecords) {
+		t.Errorf("unpaired records should be reported: %v", err)
+	}
+	if _, err := pairs(s, sampleData(4, 1), sampleData(5, 2)); !errors.Is(err, ErrUnpairedRecords) {
+		t.Errorf("unpaired records should be reported: %v", err)
+	}
+
+	// errors of parsing are not hidden by the end of the other file
+	bad := "@r5/1\nACGT\n+\nII\n"
+	if _, err := pairs(s, sampleData(4, 1)+bad, sampleData(4, 2)); err == nil || errors.Is(err, ErrUnpairedRecords) {
+		t.Errorf("error of the reader should be returned: %v", err)
+	}
+	if _, err := pairs(s, sampleData(4, 1), sampleData(4, 2)+bad); err == nil || errors.Is(err, ErrUnpairedRecords) {
+		t.Errorf("error of the reader should be returned: %v", err)
+	}
+}
@@ -0,0 +1,433 @@
+package fastx
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/shenwei356/bio/seq"
+	"github.com/shenwei356/xopen"
+)
+
+// RecordLess reports whether record a should sort before record b.
+type RecordLess func(a, b *Record) bool
+
+// ByID sorts records by IDs, natural is for the numeric-aware natural
+// ordering, e.g., read2 < read10, see CompareNatural.
+func ByID(natural bool) RecordLess {
+	if natural {
+		return func(a, b *Record) bool { return CompareNatural(a.ID, b.ID) < 0 }
+	}
+	return func(a, b *Record) bool { return bytes.Compare(a.ID, b.ID) < 0 }
+}
+
+// ByName sorts records by full names, natural is for the numeric-aware
+// natural ordering, see CompareNatural.
+func ByName(natural bool) RecordLess {
+	if natural {
+		return func(a, b *Record) bool { return CompareNatural(a.Name, b.Name) < 0 }
+	}
+	return func(a, b *Record) bool { return bytes.Compare(a.Name, b.Name) < 0 }
+}
+
+// ByLength sorts records by sequence lengths in ascending order.
+func ByLength() RecordLess {
+	return func(a, b *Record) bool { return len(a.Seq.Seq) < len(b.Seq.Seq) }
+}
+
+// BySeq sorts records by sequences.
+func BySeq(ignoreCase bool) RecordLess {
+	if ignoreCase {
+		return func(a, b *Record) bool {
+			return bytes.Compare(bytes.ToUpper(a.Seq.Seq), bytes.ToUpper(b.Seq.Seq)) < 0
+		}
+	}
+	return func(a, b *Record) bool { return bytes.Compare(a.Seq.Seq, b.Seq.Seq) < 0 }
+}
+
+// Reverse reverses the order.
+func Reverse(less RecordLess) RecordLess {
+	return func(a, b *Record) bool { return less(b, a) }
+}
+
+// CompareNatural compares two strings in the numeric-aware natural order,
+// where runs of digits are compared by their values, e.g., "read2" < "read10".
+// It returns 0 only for identical strings.
+func CompareNatural(a, b []byte) int {
+	var i, j, ei, ej int
+	var da, db []byte
+	tie := 0 // for numbers with different leading zeros, e.g., 01 and 1
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			for ei = i; ei < len(a) && isDigit(a[ei]); ei++ {
+			}
+			for ej = j; ej < len(b) && isDigit(b[ej]); ej++ {
+			}
+			da, db = bytes.TrimLeft(a[i:ei], "0"), bytes.TrimLeft(b[j:ej], "0")
+			if len(da) != len(db) {
+				if len(da) < len(db) {
+					return -1
+				}
+				return 1
+			}
+			if c := bytes.Compare(da, db); c != 0 {
+				return c
+			}
+			if tie == 0 && ei-i != ej-j {
+				if ei-i < ej-j {
+					tie = -1
+				} else {
+					tie = 1
+				}
+			}
+			i, j = ei, ej
+			continue
+		}
+		if a[i] != b[j] {
+			if a[i] < b[j] {
+				return -1
+			}
+			return 1
+		}
+		i++
+		j++
+	}
+	switch {
+	case i < len(a):
+		return 1
+	case j < len(b):
+		return -1
+	}
+	return tie
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+// DefaultSortMaxMemory is the default maximum memory of records in Sorter, i.e., 1 GB.
+var DefaultSortMaxMemory int64 = 1 << 30
+
+// DefaultSortMaxOpenRuns is the default maximum number of temporary files
+// merged at once in Sorter.
+var DefaultSortMaxOpenRuns = 64
+
+// ErrSorterFinished means records are added to a Sorter after sorting.
+var ErrSorterFinished = errors.New("fastx: sorter already finished")
+
+// SortOptions contains the options of sorting records.
+type SortOptions struct {
+	Less RecordLess // nil for ByID(true)
+
+	// MaxMemory is the approximate maximum bytes of records held in memory,
+	// sorted runs are spilled to temporary files when it's reached.
+	// 0 for DefaultSortMaxMemory.
+	MaxMemory int64
+	TmpDir    string // directory of temporary files, "" for os.TempDir()
+
+	// MaxOpenRuns is the maximum number of temporary files merged at once,
+	// runs are merged in multiple passes if there are more of them.
+	// 0 for DefaultSortMaxOpenRuns.
+	MaxOpenRuns int
+
+	// IDRegexp is for parsing IDs of records from temporary files,
+	// which should be the same as the one of the input reader, "" for default.
+	IDRegexp string
+}
+
+// Sorter sorts FASTA/Q records with limited memory. Records are kept in
+// memory until the limit is reached, then they are sorted and spilled to
+// zstd-compressed temporary files, which are merged in the end, in multiple
+// passes if there are more than SortOptions.MaxOpenRuns of them.
+// Sorting is stable, i.e., records with equal keys keep the order of adding.
+//
+// Sorter is not safe for concurrent use.
+type Sorter struct {
+	opt  SortOptions
+	less RecordLess
+
+	records  []*Record
+	mem      int64
+	runs     []string
+	alphabet *seq.Alphabet
+	finished bool
+}
+
+// NewSorter creates a Sorter, nil opt is for the default options.
+func NewSorter(opt *SortOptions) (*Sorter, error) {
+	if opt == nil {
+		opt = &SortOptions{}
+	}
+	s := &Sorter{opt: *opt, less: opt.Less}
+	if s.less == nil {
+		s.less = ByID(true)
+	}
+	if s.opt.MaxMemory <= 0 {
+		s.opt.MaxMemory = DefaultSortMaxMemory
+	}
+	if s.opt.MaxOpenRuns == 0 {
+		s.opt.MaxOpenRuns = DefaultSortMaxOpenRuns
+	} else if s.opt.MaxOpenRuns < 2 {
+		return nil, fmt.Errorf("fastx: invalid maximum number of open runs: %d, should be >= 2", s.opt.MaxOpenRuns)
+	}
+	if s.opt.IDRegexp != "" && !reCheckIDregexpStr.MatchString(s.opt.IDRegexp) {
+		return nil, fmt.Errorf(`fastx: regular expression must contain "(" and ")" to capture matched ID. default: %s`, DefaultIDRegexp)
+	}
+	return s, nil
+}
+
+// recordSize returns the approximate memory of a record.
+func recordSize(r *Record) int64 {
+	return int64(len(r.ID)+len(r.Name)+len(r.Desc)+len(r.Seq.Seq)+len(r.Seq.Qual)) + 200
+}
+
+// Add adds a copy of the record.
+func (s *Sorter) Add(record *Record) error {
+	if s.finished {
+		return ErrSorterFinished
+	}
+	if s.alphabet == nil {
+		s.alphabet = record.Seq.Alphabet
+	}
+	r := record.Clone()
+	s.records = append(s.records, r)
+	s.mem += recordSize(r)
+	if s.mem >= s.opt.MaxMemory {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill sorts the records in memory and writes them to a temporary file.
+func (s *Sorter) spill() error {
+	sort.SliceStable(s.records, func(i, j int) bool { return s.less(s.records[i], s.records[j]) })
+
+	outfh, err := s.newRun()
+	if err != nil {
+		return err
+	}
+	for _, r := range s.records {
+		r.FormatToWriter(outfh, 0)
+	}
+	if err = outfh.Close(); err != nil {
+		return fmt.Errorf("fastx: %s", err)
+	}
+
+	s.records = nil
+	s.mem = 0
+	return nil
+}
+
+// newRun creates a temporary file for a sorted run.
+func (s *Sorter) newRun() (*xopen.Writer, error) {
+	fh, err := os.CreateTemp(s.opt.TmpDir, "fastx-sort-*.zst")
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+	file := fh.Name()
+	fh.Close()
+	s.runs = append(s.runs, file)
+
+	outfh, err := xopen.Wopen(file)
+	if err != nil {
+		return nil, fmt.Errorf("fastx: %s", err)
+	}
+	return outfh, nil
+}
+
+// Sort calls fn for all records in the sorted order, and removes temporary
+// files. Records from temporary files are only valid during the calls,
+// please clone them to keep. No records could be added after it.
+func (s *Sorter) Sort(fn func(record *Record) error) error {
+	if s.finished {
+		return ErrSorterFinished
+	}
+	s.finished = true
+	defer s.Close()
+
+	if len(s.runs) == 0 { // all in memory
+		sort.SliceStable(s.records, func(i, j int) bool { return s.less(s.records[i], s.records[j]) })
+		for _, r := range s.records {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		s.records = nil
+		return nil
+	}
+
+	if len(s.records) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+
+	for len(s.runs) > s.opt.MaxOpenRuns {
+		if err := s.mergePass(); err != nil {
+			return err
+		}
+	}
+	return s.merge(s.runs, fn)
+}
+
+// mergePass merges every MaxOpenRuns runs into one. Merged runs are
+// in the original order, so sorting is still stable.
+func (s *Sorter) mergePass() error {
+	runs := s.runs
+	merged := make([]string, 0, (len(runs)-1)/s.opt.MaxOpenRuns+1)
+	var group []string
+	for i := 0; i < len(runs); i += s.opt.MaxOpenRuns {
+		if i+s.opt.MaxOpenRuns < len(runs) {
+			group = runs[i : i+s.opt.MaxOpenRuns]
+		} else {
+			group = runs[i:]
+		}
+		if len(group) == 1 {
+			merged = append(merged, group[0])
+			continue
+		}
+
+		outfh, err := s.newRun() // the new run is also removed by Close on errors
+		if err != nil {
+			return err
+		}
+		merged = append(merged, s.runs[len(s.runs)-1])
+		err = s.merge(group, func(record *Record) error {
+			record.FormatToWriter(outfh, 0)
+			return nil
+		})
+		if e := outfh.Close(); err == nil && e != nil {
+			err = fmt.Errorf("fastx: %s", e)
+		}
+		if err != nil {
+			return err
+		}
+		for _, file := range group {
+			if err = os.Remove(file); err != nil {
+				return fmt.Errorf("fastx: %s", err)
+			}
+		}
+	}
+	s.runs = merged
+	return nil
+}
+
+// merge calls fn for records of sorted runs in the sorted order.
+func (s *Sorter) merge(runs []string, fn func(record *Record) error) error {
+	readers := make([]*Reader, 0, len(runs))
+	defer func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}()
+	h := &runHeap{less: s.less}
+	for i, file := range runs {
+		reader, err := NewReader(s.alphabet, file, s.opt.IDRegexp)
+		if err != nil {
+			return err
+		}
+		readers = append(readers, reader)
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				continue
+			}
+			return err
+		}
+		h.items = append(h.items, runHead{record, i})
+	}
+	heap.Init(h)
+
+	var record *Record
+	var err error
+	for h.Len() > 0 {
+		item := &h.items[0]
+		if err = fn(item.record); err != nil {
+			return err
+		}
+		record, err = readers[item.run].Read()
+		if err != nil {
+			if err == io.EOF {
+				heap.Pop(h)
+				continue
+			}
+			return err
+		}
+		item.record = record
+		heap.Fix(h, 0)
+	}
+	return nil
+}
+
+// Close removes temporary files. It's called by Sort, and only needed
+// when Sort is not called.
+func (s *Sorter) Close() error {
+	var err error
+	for _, file := range s.runs {
+		if e := os.Remove(file); e != nil && !os.IsNotExist(e) {
+			err = fmt.Errorf("fastx: %s", e)
+		}
+	}
+	s.runs = nil
+	return err
+}
+
+// SortReader reads all records from the reader and calls fn for them
+// in the sorted order, see Sorter. The reader is not closed.
+func SortReader(reader *Reader, opt *SortOptions, fn func(record *Record) error) error {
+	s, err := NewSorter(opt)
+	if err != nil {
+		return err
+	}
+	var record *Record
+	for {
+		record, err = reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			s.Close()
+			return err
+		}
+		if err = s.Add(record); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	return s.Sort(fn)
+}
+
+// runHead is the current record of a sorted run.
+type runHead struct {
+	record *Record
+	run    int
+}
+
+// runHeap is a min-heap of the current records of runs,
+// ties are broken by the order of runs for stable sorting.
+type runHeap struct {
+	items []runHead
+	less  RecordLess
+}
+
+func (h *runHeap) Len() int { return len(h.items) }
+func (h *runHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.less(a.record, b.record) {
+		return true
+	}
+	if h.less(b.record, a.record) {
+		return false
+	}
+	return a.run < b.run
+}
+func (h *runHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *runHeap) Push(x interface{}) { h.items = append(h.items, x.(runHead)) }
+func (h *runHeap) Pop() interface{} {
+	n := len(h.items)
+	x := h.items[n-1]
+	h.items = h.items[:n-1]
+	return x
+}
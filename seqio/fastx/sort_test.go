@@ -0,0 +1,111 @@
+package fastx
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a, b string
+		c    int
+	}{
+		{"read2", "read10", -1},
+		{"read10", "read10", 0},
+		{"read10a", "read10", 1},
+		{"r1.2", "r1.10", -1},
+		{"read01", "read1", 1},
+		{"read01", "read2", -1},
+		{"a", "b", -1},
+		{"", "1", -1},
+	}
+	for _, test := range tests {
+		if c := CompareNatural([]byte(test.a), []byte(test.b)); c != test.c {
+			t.Errorf("compare %s and %s: expected %d, returned %d", test.a, test.b, test.c, c)
+		}
+	}
+}
+
+const sortData = "@read10\nACGTAC\n+\nIIIIII\n@read2\nAC\n+\nII\n@read1\nACGT\n+\nIIII\n" +
+	"@read2 dup\nACG\n+\nIII\n@read11\nA\n+\nI\n"
+
+// sortStage sorts records with SortReader, and checks qualities of sorted records.
+func sortStage(t *testing.T, opt *SortOptions) func(reader *Reader, emit func(*Record) error) error {
+	return func(reader *Reader, emit func(*Record) error) error {
+		return SortReader(reader, opt, func(record *Record) error {
+			if len(record.Seq.Qual) != len(record.Seq.Seq) {
+				t.Errorf("quality lost: %s", record.Name)
+			}
+			return emit(record)
+		})
+	}
+}
+
+func TestSorter(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := []struct {
+		less     RecordLess
+		expected string
+	}{
+		{nil, "read1,read2,read2 dup,read10,read11"},
+		{ByID(false), "read1,read10,read11,read2,read2 dup"},
+		{Reverse(ByLength()), "read10,read1,read2 dup,read2,read11"},
+		{BySeq(false), "read11,read2,read2 dup,read1,read10"},
+	}
+	for i, test := range tests {
+		for _, maxMem := range []int64{0, 1, 500} { // in memory, one record per run, and more
+			for _, maxRuns := range []int{0, 2, 3} { // merging in one or multiple passes
+				names, err := runStage(t, sortData, sortStage(t, &SortOptions{Less: test.less, MaxMemory: maxMem,
+					TmpDir: tmpDir, MaxOpenRuns: maxRuns}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if names != test.expected {
+					t.Errorf("#%d (%d, %d): expected %s, returned %s", i, maxMem, maxRuns, test.expected, names)
+				}
+			}
+		}
+	}
+
+	// temporary files are removed after errors of fn and the reader
+	runStage(t, sortData, func(reader *Reader, emit func(*Record) error) error {
+		return SortReader(reader, &SortOptions{MaxMemory: 1, TmpDir: tmpDir},
+			func(record *Record) error { return errors.New("stop") })
+	})
+	runStage(t, sortData+"@bad\nACGT\n+\nII\n", sortStage(t, &SortOptions{MaxMemory: 1, TmpDir: tmpDir}))
+	runStage(t, sortData, func(reader *Reader, emit func(*Record) error) error {
+		return SortReader(reader, &SortOptions{MaxMemory: 1, TmpDir: tmpDir, MaxOpenRuns: 2},
+			func(record *Record) error { return errors.New("stop") })
+	})
+	files, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) > 0 {
+		t.Errorf("temporary files not removed: %d", len(files))
+	}
+
+	if _, err = NewSorter(&SortOptions{MaxOpenRuns: 1}); err == nil {
+		t.Errorf("invalid options should be reported")
+	}
+
+	// no records could be added after sorting
+	s, err := NewSorter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, _ := NewRecordWithoutValidation(seq.DNA, []byte("r1"), []byte("r1"), nil, []byte("ACGT"))
+	s.Add(record)
+	if err = s.Sort(func(record *Record) error { return nil }); err != nil {
+		t.Error(err)
+	}
+	if err = s.Add(record); !errors.Is(err, ErrSorterFinished) {
+		t.Errorf("adding records after sorting should be reported: %v", err)
+	}
+	if err = s.Sort(func(record *Record) error { return nil }); !errors.Is(err, ErrSorterFinished) {
+		t.Errorf("sorting twice should be reported: %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright © 2018-2021 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sketches
+
+import (
+	"fmt"
+
+	"github.com/shenwei356/bio/seq"
+	"github.com/shenwei356/kmers"
+)
+
+// base2bitRC maps bases to 2-bit codes of their complements, 4 for illegal bases.
+var base2bitRC [256]uint64
+
+func init() {
+	var c byte
+	var err error
+	for i, code := range base2bit {
+		if code == 4 {
+			base2bitRC[i] = 4
+			continue
+		}
+		c, err = seq.DNAredundant.PairLetter(byte(i))
+		if err != nil { // U, u
+			c = 'A'
+		}
+		base2bitRC[i] = base2bit[c]
+	}
+}
+
+// seeds of ntHash, see github.com/will-rowe/nthash.
+const (
+	ntSeedA uint64 = 0x3c8bfbb395c60474
+	ntSeedC uint64 = 0x3193c18562a02b4c
+	ntSeedG uint64 = 0x20323ed082572324
+	ntSeedT uint64 = 0x295549f54be24456
+)
+
+// ntSeed returns the seed of a base for the forward strand.
+func ntSeed(b byte) uint64 {
+	switch b {
+	case 'A', 'a':
+		return ntSeedA
+	case 'C', 'c':
+		return ntSeedC
+	case 'G', 'g':
+		return ntSeedG
+	case 'T', 't', 'U', 'u':
+		return ntSeedT
+	}
+	return 0
+}
+
+// ntSeedRC is the lookup table of seeds of complement bases, indexed by b&7.
+var ntSeedRC = [8]uint64{0, ntSeedT, 0, ntSeedG, ntSeedA, ntSeedA, 0, ntSeedC}
+
+func roL(v uint64, n uint) uint64 {
+	if n&63 == 0 {
+		return v
+	}
+	return v<<n | v>>(64-n)
+}
+
+func roR(v uint64, n uint) uint64 {
+	if n&63 == 0 {
+		return v
+	}
+	return v>>n | v<<(64-n)
+}
+
+// PackedIterator is a k-mer code (k<=32) or ntHash iterator of bit-packed
+// sequences (seq.PackedSeq and seq.PackedSeq4), which reads bases directly
+// from the packed data without unpacking the whole sequence.
+// The outputs are the same as the ones of Iterator on the unpacked sequence.
+type PackedIterator struct {
+	s         seq.PackedSequence
+	k         int
+	kUint     uint
+	canonical bool
+	circular  bool
+	hash      bool
+
+	length int // sequence length
+	n      int // length of the sequence, plus k-1 for circular sequences
+	end    int
+	idx    int
+
+	finished     bool
+	revcomStrand bool
+	first        bool
+
+	// for k-mer codes
+	preCode   uint64
+	preCodeRC uint64
+	mask1     uint64
+	mask2     uint
+
+	// for ntHash
+	fh, rh uint64
+
+	// for seq.PackedSeq, runs of other letters are located with cursors,
+	// i.e., indexes of the first runs ending after the last read positions,
+	// which move along with the positions instead of searching runs per base.
+	packed     *seq.PackedSeq
+	runs       []seq.BaseRun
+	head, tail int
+}
+
+func newPackedIterator(s seq.PackedSequence, k int, canonical bool, circular bool) (*PackedIterator, error) {
+	if k < 1 {
+		return nil, ErrInvalidK
+	}
+	if s.Len() < k {
+		return nil, ErrShortSeq
+	}
+	iter := &PackedIterator{s: s, k: k, kUint: uint(k), canonical: canonical, circular: circular}
+	iter.length = s.Len()
+	iter.n = iter.length
+	if circular {
+		iter.n += k - 1
+	}
+	iter.end = iter.n - k + 1
+	iter.first = true
+	if p, ok := s.(*seq.PackedSeq); ok {
+		iter.packed = p
+		iter.runs = p.Runs()
+	}
+	return iter, nil
+}
+
+// NewPackedKmerIterator returns a k-mer code iterator of a bit-packed sequence.
+// Like NewKmerIterator, k-mers of the reverse complement strand are returned
+// after the ones of the positive strand if canonical is false.
+func NewPackedKmerIterator(s seq.PackedSequence, k int, canonical bool, circular bool) (*PackedIterator, error) {
+	if k > 32 {
+		return nil, ErrKTooLarge
+	}
+	iter, err := newPackedIterator(s, k, canonical, circular)
+	if err != nil {
+		return nil, err
+	}
+	iter.mask1 = (1 << (uint(k-1) << 1)) - 1
+	iter.mask2 = uint(k-1) << 1
+	return iter, nil
+}
+
+// NewPackedHashIterator returns an ntHash iterator of a bit-packed sequence.
+func NewPackedHashIterator(s seq.PackedSequence, k int, canonical bool, circular bool) (*PackedIterator, error) {
+	iter, err := newPackedIterator(s, k, canonical, circular)
+	if err != nil {
+		return nil, err
+	}
+	iter.hash = true
+	return iter, nil
+}
+
+// base returns the base at the 0-based position i of the sequence
+// (with the first k-1 bases appended for circular sequences),
+// where cur is the cursor of runs for seq.PackedSeq.
+func (iter *PackedIterator) base(i int, cur *int) byte {
+	if i >= iter.length {
+		i -= iter.length
+	}
+	if iter.packed == nil {
+		return iter.s.At(i)
+	}
+
+	runs := iter.runs
+	j := *cur
+	for j > 0 && runs[j-1].Start+runs[j-1].Length > i {
+		j--
+	}
+	for j < len(runs) && runs[j].Start+runs[j].Length <= i {
+		j++
+	}
+	*cur = j
+	if j < len(runs) && runs[j].Start <= i {
+		return runs[j].Letter
+	}
+	return "ACGT"[iter.packed.Code(i)] // U of RNA is treated as T
+}
+
+// code returns the 2-bit code of the base at the 0-based position i of the
+// positive or the reverse complement strand.
+func (iter *PackedIterator) code(i int) (uint64, error) {
+	var b byte
+	var c uint64
+	if iter.revcomStrand {
+		b = iter.base(iter.n-1-i, &iter.head)
+		c = base2bitRC[b]
+	} else {
+		b = iter.base(i, &iter.head)
+		c = base2bit[b]
+	}
+	if c == 4 {
+		return 0, fmt.Errorf("%w: %c", ErrIllegalBase, b)
+	}
+	return c, nil
+}
+
+// NextKmer returns next k-mer code.
+func (iter *PackedIterator) NextKmer() (code uint64, ok bool, err error) {
+	if iter.finished {
+		return 0, false, nil
+	}
+
+	if iter.idx == iter.end {
+		if iter.canonical || iter.revcomStrand {
+			iter.finished = true
+			return 0, false, nil
+		}
+		iter.idx = 0
+		iter.revcomStrand = true
+		iter.first = true
+	}
+
+	var c uint64
+	if !iter.first {
+		c, err = iter.code(iter.idx + iter.k - 1)
+		if err != nil {
+			return 0, false, err
+		}
+		code = (iter.preCode&iter.mask1)<<2 | c
+		iter.preCodeRC = (c^3)<<iter.mask2 | (iter.preCodeRC >> 2)
+	} else {
+		for i := 0; i < iter.k; i++ {
+			c, err = iter.code(iter.idx + i)
+			if err != nil {
+				return 0, false, err
+			}
+			code = code<<2 | c
+		}
+		iter.preCodeRC = kmers.MustRevComp(code, iter.k)
+		iter.first = false
+	}
+
+	iter.preCode = code
+	iter.idx++
+
+	if iter.canonical && code > iter.preCodeRC {
+		code = iter.preCodeRC
+	}
+	return code, true, nil
+}
+
+// NextHash returns next ntHash.
+func (iter *PackedIterator) NextHash() (code uint64, ok bool) {
+	if iter.finished {
+		return 0, false
+	}
+	if iter.idx == iter.end {
+		iter.finished = true
+		return 0, false
+	}
+
+	var b byte
+	if iter.first {
+		iter.fh, iter.rh = 0, 0
+		for i := 0; i < iter.k; i++ {
+			iter.fh = roL(iter.fh, 1) ^ ntSeed(iter.base(i, &iter.head))
+			iter.rh = roL(iter.rh, 1) ^ ntSeedRC[iter.base(iter.k-1-i, &iter.tail)&7]
+		}
+		iter.first = false
+	} else {
+		b = iter.base(iter.idx-1, &iter.tail)
+		iter.fh = roL(iter.fh, 1) ^ roL(ntSeed(b), iter.kUint)
+		iter.rh = roR(iter.rh, 1) ^ roR(ntSeedRC[b&7], 1)
+
+		b = iter.base(iter.idx+iter.k-1, &iter.head)
+		iter.fh ^= ntSeed(b)
+		iter.rh ^= roL(ntSeedRC[b&7], iter.kUint-1)
+	}
+	iter.idx++
+
+	if iter.canonical && iter.rh < iter.fh {
+		return iter.rh, true
+	}
+	return iter.fh, true
+}
+
+// Next is a wrapper for NextHash and NextKmer.
+func (iter *PackedIterator) Next() (code uint64, ok bool, err error) {
+	if iter.hash {
+		code, ok = iter.NextHash()
+		return
+	}
+	return iter.NextKmer()
+}
+
+// Index returns current 0-baesd index.
+func (iter *PackedIterator) Index() int {
+	return iter.idx - 1
+}
@@ -0,0 +1,181 @@
+// Copyright © 2018-2021 Wei Shen <shenwei356@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sketches
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/shenwei356/bio/seq"
+)
+
+func TestPackedIterator(t *testing.T) {
+	_s := "AAGTTTGAATCATTCAACTATCTAGTTTTCAGAGAACAATGTTCTCTAAAGAATAGAAAAGAGTCATTGTGCGGTGATGATGGCGGGAAGGATCCACCTG" +
+		"NNNNNACGTRYKMSWBDHVACGTCCAGTTGACGATCGGGCATNNNACGT"
+	s, _ := seq.NewSeq(seq.DNAredundant, []byte(_s))
+	p2, err := seq.NewPackedSeq(s)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	p4, err := seq.NewPackedSeq4(s)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	k := 11
+
+	type next func() (uint64, bool, error)
+	collect := func(next next) []uint64 {
+		codes := make([]uint64, 0, 256)
+		for {
+			code, ok, err := next()
+			if err != nil {
+				t.Error(err)
+				return nil
+			}
+			if !ok {
+				break
+			}
+			codes = append(codes, code)
+		}
+		return codes
+	}
+
+	var iter *Iterator
+	var piter *PackedIterator
+	var c1, c2 []uint64
+	for _, hash := range []bool{false, true} {
+		for _, canonical := range []bool{false, true} {
+			for _, circular := range []bool{false, true} {
+				if hash {
+					iter, err = NewHashIterator(s.Clone(), k, canonical, circular)
+				} else {
+					iter, err = NewKmerIterator(s.Clone(), k, canonical, circular)
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				c1 = collect(iter.Next)
+
+				for _, p := range []seq.PackedSequence{p2, p4} {
+					if hash {
+						piter, err = NewPackedHashIterator(p, k, canonical, circular)
+					} else {
+						piter, err = NewPackedKmerIterator(p, k, canonical, circular)
+					}
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					c2 = collect(piter.Next)
+
+					if len(c1) == 0 || len(c1) != len(c2) {
+						t.Errorf("packed codes number error (hash: %v, canonical: %v, circular: %v): %d, expected: %d",
+							hash, canonical, circular, len(c2), len(c1))
+						continue
+					}
+					for i := range c1 {
+						if c1[i] != c2[i] {
+							t.Errorf("packed codes differ at %d (hash: %v, canonical: %v, circular: %v)",
+								i, hash, canonical, circular)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	g, _ := seq.NewSeq(seq.DNAredundant, []byte("ACGTACGT-ACGTACGT"))
+	pg, _ := seq.NewPackedSeq(g)
+	piter, _ = NewPackedKmerIterator(pg, 5, true, false)
+	for {
+		_, ok, err := piter.Next()
+		if err != nil {
+			if !errors.Is(err, ErrIllegalBase) {
+				t.Errorf("unexpected error: %s", err)
+			}
+			break
+		}
+		if !ok {
+			t.Errorf("illegal base should be reported")
+			break
+		}
+	}
+}
+
+// TestPackedIteratorRandom checks that hashes of PackedIterator, which are
+// computed with copied seeds of ntHash, are the same as the ones of Iterator.
+func TestPackedIteratorRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	bases := []byte("ACGT")
+	others := []byte("NNNNRYKMSWBDHV")
+	k := 15
+	var code1, code2 uint64
+	var ok1, ok2 bool
+	for i := 0; i < 200; i++ {
+		s := make([]byte, k+r.Intn(500))
+		for j := 0; j < len(s); j++ {
+			if r.Intn(50) == 0 { // runs of N and degenerate bases
+				b := others[r.Intn(len(others))]
+				for n := 1 + r.Intn(20); n > 0 && j < len(s); n-- {
+					s[j] = b
+					j++
+				}
+				j--
+				continue
+			}
+			s[j] = bases[r.Intn(4)]
+		}
+		sequence, _ := seq.NewSeq(seq.DNAredundant, s)
+		p2, _ := seq.NewPackedSeq(sequence)
+		p4, _ := seq.NewPackedSeq4(sequence)
+
+		for _, canonical := range []bool{false, true} {
+			for _, circular := range []bool{false, true} {
+				for _, p := range []seq.PackedSequence{p2, p4} {
+					iter, err := NewHashIterator(sequence.Clone(), k, canonical, circular)
+					if err != nil {
+						t.Fatal(err)
+					}
+					piter, err := NewPackedHashIterator(p, k, canonical, circular)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for {
+						code1, ok1 = iter.NextHash()
+						code2, ok2 = piter.NextHash()
+						if ok1 != ok2 || code1 != code2 {
+							t.Fatalf("packed hashes differ at %d (canonical: %v, circular: %v): %s",
+								piter.Index(), canonical, circular, s)
+						}
+						if !ok1 {
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+}
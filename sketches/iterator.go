@@ -110,6 +110,8 @@ type Iterator struct {
 //	k:     k-mer size.
 //	m:     size of m-mer in a k-mer. range: [4, k]
 //	scale: scale of FracMinHash of m-mers. range: [1, k-m+1]
+//
+// RNA sequences are supported, where U is treated as T.
 func NewSimHashIterator(s *seq.Seq, k int, m int, scale int, canonical bool, circular bool) (*Iterator, error) {
 	if k < 1 {
 		return nil, ErrInvalidK
@@ -166,6 +168,7 @@ func NewSimHashIterator(s *seq.Seq, k int, m int, scale int, canonical bool, cir
 	}
 	iter.nPosHash = 0
 
+	iter.hash = true
 	iter.simhash = true
 	iter.m = m
 	iter.em = iter.k - iter.m
@@ -611,7 +614,20 @@ func (iter *Iterator) NextSimHash() (code uint64, ok bool) {
 	return code, true
 }
 
+// seq4Hashing returns the sequence for computing ntHash, where the first k-1
+// bases are appended for circular sequences.
+// The original sequence is not edited.
+func seq4Hashing(s *seq.Seq, k int, circular bool) []byte {
+	if !circular {
+		return s.Seq
+	}
+	seq2 := make([]byte, len(s.Seq), len(s.Seq)+k-1)
+	copy(seq2, s.Seq)
+	return append(seq2, s.Seq[0:k-1]...)
+}
+
 // NewHashIterator returns ntHash Iterator.
+// RNA sequences are supported, where U is treated as T.
 func NewHashIterator(s *seq.Seq, k int, canonical bool, circular bool) (*Iterator, error) {
 	if k < 1 {
 		return nil, ErrInvalidK
@@ -631,6 +647,7 @@ func NewHashIterator(s *seq.Seq, k int, canonical bool, circular bool) (*Iterato
 	iter.idx = 0
 
 	iter.hash = true
+	iter.simhash = false
 	iter.kUint = uint(k)
 	iter.kP1 = k - 1
 	iter.kP1Uint = uint(k - 1)
@@ -638,14 +655,7 @@ func NewHashIterator(s *seq.Seq, k int, canonical bool, circular bool) (*Iterato
 	// iter.mask2 = iter.kP1Uint << 1
 
 	var err error
-	var seq2 []byte
-	if circular {
-		seq2 = make([]byte, len(s.Seq), len(s.Seq)+k-1)
-		copy(seq2, s.Seq) // do not edit original sequence
-		seq2 = append(seq2, s.Seq[0:k-1]...)
-	} else {
-		seq2 = s.Seq
-	}
+	seq2 := seq4Hashing(s, k, circular)
 	iter.hasher, err = nthash.NewHasher(&seq2, uint(k))
 	if err != nil {
 		return nil, err
@@ -665,6 +675,8 @@ func (iter *Iterator) NextHash() (code uint64, ok bool) {
 }
 
 // NewKmerIterator returns k-mer code iterator.
+// RNA sequences are supported, where U is treated as T, i.e., the k-mer codes
+// of an RNA sequence are the same as its DNA counterpart.
 func NewKmerIterator(s *seq.Seq, k int, canonical bool, circular bool) (*Iterator, error) {
 	if k < 1 {
 		return nil, ErrInvalidK
@@ -691,6 +703,8 @@ func NewKmerIterator(s *seq.Seq, k int, canonical bool, circular bool) (*Iterato
 	iter.revcomStrand = false
 	iter.idx = 0
 
+	iter.hash = false
+	iter.simhash = false
 	iter.length = len(s2.Seq)
 	iter.end = iter.length - k + 1
 	iter.kUint = uint(k)
@@ -276,3 +276,195 @@ func BenchmarkProteinIterator(b *testing.B) {
 		})
 	}
 }
+
+func TestIteratorNextDispatch(t *testing.T) {
+	_s := "AAGTTTGAATCATTCAACTATCTAGTTTTCAGAGAACAATGTTCTCTAAAGAATAGAAAAGAGTCATTGTGCGGTGATGATGGCGGGAAGGATCCACCTG"
+	sequence, _ := seq.NewSeq(seq.DNA, []byte(_s))
+	k := 21
+
+	for round := 0; round < 3; round++ { // iterators are reused from the pool
+		// Next of SimHash iterators returns SimHashes
+		iter, err := NewSimHashIterator(sequence, k, 5, 5, true, false)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		iter2, _ := NewSimHashIterator(sequence, k, 5, 5, true, false)
+		for {
+			code, ok, _ := iter.Next()
+			code2, ok2 := iter2.NextSimHash()
+			if ok != ok2 || code != code2 {
+				t.Errorf("Next of SimHash iterators error")
+				break
+			}
+			if !ok {
+				break
+			}
+		}
+
+		// ntHash and k-mer iterators from the pool do not return SimHashes
+		iter, _ = NewHashIterator(sequence, k, true, false)
+		iter2, _ = NewHashIterator(sequence, k, true, false)
+		for {
+			code, ok, _ := iter.Next()
+			code2, ok2 := iter2.NextHash()
+			if ok != ok2 || code != code2 {
+				t.Errorf("Next of hash iterators error")
+				break
+			}
+			if !ok {
+				break
+			}
+		}
+		iter, _ = NewKmerIterator(sequence, k, true, false)
+		iter2, _ = NewKmerIterator(sequence, k, true, false)
+		for {
+			code, ok, _ := iter.Next()
+			code2, ok2, _ := iter2.NextKmer()
+			if ok != ok2 || code != code2 {
+				t.Errorf("Next of k-mer iterators error")
+				break
+			}
+			if !ok {
+				break
+			}
+		}
+	}
+}
+
+func TestIteratorsOnRNA(t *testing.T) {
+	_s := "AAGTTTGAATCATTCAACTATCTAGTTTTCAGAGAACAATGTTCTCTAAAGAATAGAAAAGAGTCATTGTGCGGTGATGATGGCGGGAAGGATCCACCTG"
+	dna, _ := seq.NewSeq(seq.DNA, []byte(_s))
+	rna, err := dna.ToRNA()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	k := 10
+
+	collect := func(s *seq.Seq, hash bool, canonical bool) []uint64 {
+		var iter *Iterator
+		if hash {
+			iter, err = NewHashIterator(s.Clone(), k, canonical, false)
+		} else {
+			iter, err = NewKmerIterator(s.Clone(), k, canonical, false)
+		}
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		var code uint64
+		var ok bool
+		codes := make([]uint64, 0, 1024)
+		for {
+			code, ok, err = iter.Next()
+			if err != nil {
+				t.Error(err)
+			}
+			if !ok {
+				break
+			}
+			codes = append(codes, code)
+		}
+		return codes
+	}
+
+	var c1, c2 []uint64
+	for _, hash := range []bool{false, true} {
+		for _, canonical := range []bool{false, true} {
+			c1, c2 = collect(dna, hash, canonical), collect(rna, hash, canonical)
+			if len(c1) == 0 || len(c1) != len(c2) {
+				t.Errorf("RNA codes number error (hash: %v, canonical: %v)", hash, canonical)
+				continue
+			}
+			for i := range c1 {
+				if c1[i] != c2[i] {
+					t.Errorf("RNA codes differ from DNA ones (hash: %v, canonical: %v)", hash, canonical)
+					break
+				}
+			}
+		}
+	}
+
+	// minimizers
+	c1, c2 = c1[:0], c2[:0]
+	for i, s := range []*seq.Seq{dna, rna} {
+		sketch, err := NewMinimizerSketch(s, k, 5, false)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for {
+			code, ok := sketch.Next()
+			if !ok {
+				break
+			}
+			if i == 0 {
+				c1 = append(c1, code)
+			} else {
+				c2 = append(c2, code)
+			}
+		}
+	}
+	if len(c1) == 0 || len(c1) != len(c2) {
+		t.Errorf("RNA minimizers number error")
+		return
+	}
+	for i := range c1 {
+		if c1[i] != c2[i] {
+			t.Errorf("RNA minimizers differ from DNA ones")
+			break
+		}
+	}
+}
+
+func TestBase2bitOfU(t *testing.T) {
+	for _, b := range []byte("Uu") {
+		if base2bit[b] != base2bit['T'] {
+			t.Errorf("base2bit of %c should be the same as T", b)
+		}
+	}
+}
+
+func TestHashesOfRNA(t *testing.T) {
+	dna, _ := seq.NewSeq(seq.DNA, []byte("ACGTTGCATTACGGAt"))
+	rna, _ := seq.NewSeq(seq.RNA, []byte("ACGUUGCAUUACGGAu"))
+	k := 5
+
+	// ntHash treats U/u as T/t, no conversion is needed
+	for _, circular := range []bool{false, true} {
+		iter1, err := NewHashIterator(dna, k, true, circular)
+		if err != nil {
+			t.Fatal(err)
+		}
+		iter2, _ := NewHashIterator(rna, k, true, circular)
+		for {
+			code1, ok1 := iter1.NextHash()
+			code2, ok2 := iter2.NextHash()
+			if ok1 != ok2 || code1 != code2 {
+				t.Errorf("hashes of RNA differ from DNA ones (circular: %v)", circular)
+				break
+			}
+			if !ok1 {
+				break
+			}
+		}
+	}
+
+	iter1, err := NewSimHashIterator(dna, 10, 4, 1, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter2, _ := NewSimHashIterator(rna, 10, 4, 1, true, false)
+	for {
+		code1, ok1 := iter1.NextSimHash()
+		code2, ok2 := iter2.NextSimHash()
+		if ok1 != ok2 || code1 != code2 {
+			t.Errorf("SimHashes of RNA differ from DNA ones")
+			break
+		}
+		if !ok1 {
+			break
+		}
+	}
+}
@@ -20,6 +20,11 @@
 
 package sketches
 
+// base2bit maps bases to 2-bit codes: A/C/G/T to 0/1/2/3, and degenerate bases
+// are mapped to the code of one of the bases they represent. 4 is for illegal bases.
+//
+// U/u are intentionally mapped to 3 like T/t, so k-mer codes of RNA sequences
+// are the same as their DNA counterparts.
 var base2bit = [256]uint64{
 	4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4,
 	4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4,
@@ -110,7 +110,7 @@ func (s *ProteinMinimizerSketch) Next() (code uint64, ok bool) {
 		// }
 
 		if s.idx > s.end0 {
-			poolProteinIterator.Put(s)
+			poolProteinMinimizerSketch.Put(s)
 			return 0, false
 		}
 
@@ -56,3 +56,33 @@ func TestProteinMinimizer(t *testing.T) {
 		codes = append(codes, code)
 	}
 }
+
+func TestProteinMinimizerPool(t *testing.T) {
+	_s := "AAGTTTGAATCATTCAACTATCTAGTTTTCAGAGAACAATGTTCTCTAAAGAATAGAAAAGAGTCATTGTGCGGTGATGATGGCGGGAAGGATCCACCTG"
+	sequence, _ := seq.NewSeq(seq.DNA, []byte(_s))
+
+	// finished sketches must be put back to their own pool,
+	// otherwise creating protein iterators panics.
+	for i := 0; i < 3; i++ {
+		sketch, err := NewProteinMinimizerSketch(sequence, 10, 1, 1, 3)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for {
+			if _, ok := sketch.Next(); !ok {
+				break
+			}
+		}
+		iter, err := NewProteinIterator(sequence, 10, 1, 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for {
+			if _, ok := iter.Next(); !ok {
+				break
+			}
+		}
+	}
+}
@@ -82,6 +82,7 @@ var poolSketch = &sync.Pool{New: func() interface{} {
 
 // NewMinimizerSketch returns a SyncmerSketch Iterator.
 // It returns the minHashes in all windows of w (w>=1) bp.
+// RNA sequences are supported, where U is treated as T.
 func NewMinimizerSketch(S *seq.Seq, k int, w int, circular bool) (*Sketch, error) {
 	if k < 1 {
 		return nil, ErrInvalidK
@@ -102,14 +103,9 @@ func NewMinimizerSketch(S *seq.Seq, k int, w int, circular bool) (*Sketch, error
 	sketch.circular = circular
 	sketch.skip = w == 1
 
-	var seq2 []byte
+	seq2 := seq4Hashing(S, k, circular)
 	if circular {
-		seq2 = make([]byte, len(S.Seq), len(S.Seq)+k-1)
-		copy(seq2, S.Seq) // do not edit original sequence
-		seq2 = append(seq2, S.Seq[0:k-1]...)
 		sketch.S = seq2
-	} else {
-		seq2 = S.Seq
 	}
 
 	sketch.idx = 0
@@ -139,6 +135,7 @@ func NewMinimizerSketch(S *seq.Seq, k int, w int, circular bool) (*Sketch, error
 
 // NewSyncmerSketch returns a SyncmerSketch Iterator.
 // 1<=s<=k.
+// RNA sequences are supported, where U is treated as T.
 func NewSyncmerSketch(S *seq.Seq, k int, s int, circular bool) (*Sketch, error) {
 	if k < 1 {
 		return nil, ErrInvalidK
@@ -159,14 +156,9 @@ func NewSyncmerSketch(S *seq.Seq, k int, s int, circular bool) (*Sketch, error)
 	sketch.circular = circular
 	sketch.skip = s == k
 
-	var seq2 []byte
+	seq2 := seq4Hashing(S, k, circular)
 	if circular {
-		seq2 = make([]byte, len(S.Seq), len(S.Seq)+k-1)
-		copy(seq2, S.Seq) // do not edit original sequence
-		seq2 = append(seq2, S.Seq[0:k-1]...)
 		sketch.S = seq2
-	} else {
-		seq2 = S.Seq
 	}
 
 	sketch.idx = 0